@@ -1,11 +1,20 @@
 package app
 
 import (
+	"battleship-client/config"
+	"battleship-client/fleet"
+	"battleship-client/game"
+	"battleship-client/history"
 	"battleship-client/http"
-	"bufio"
+	"battleship-client/layout"
+	"battleship-client/replay"
+	"battleship-client/session"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"math/rand"
 	"strconv"
 	"strings"
 	"time"
@@ -14,218 +23,347 @@ import (
 )
 
 type App struct {
-	client                *http.Client
-	player                string
-	playerDescription     string
-	opponent              string
-	opponentDescription   string
-	ui                    *gui.GUI
-	playerShips           []string
-	playerBoard           *gui.Board
-	opponentBoard         *gui.Board
-	playerStates          [10][10]gui.State
-	opponentStates        [10][10]gui.State
-	opponentShots         []string
-	shouldFire            bool
-	yourTurnTxt           *gui.Text
-	opponentTurnTxt       *gui.Text
-	timerTxt              *gui.Text
-	timer                 int
-	accuracyTxt           *gui.Text
-	shotsFired            int
-	shotsHit              int
-	lastGameStatus        string
-	cancelFunc            func()
-	opponentShips         map[int]int
-	fourTileShipsInfoTxt  *gui.Text
-	threeTileShipsInfoTxt *gui.Text
-	twoTileShipsInfoTxt   *gui.Text
-	oneTileShipsInfoTxt   *gui.Text
-	customShips           []string
-}
-
-func NewApp(client *http.Client, reader *bufio.Reader, trimFunc func(rune) bool, description string, nick string) {
+	client              *http.Client
+	serverURL           string
+	logger              *slog.Logger
+	player              string
+	playerDescription   string
+	opponent            string
+	opponentDescription string
+	ui                  *gui.GUI
+	playerShips         []game.Coord
+	playerBoard         *gui.Board
+	opponentBoard       *gui.Board
+	game                *game.Game
+	shouldFire          bool
+	turn                int
+	yourTurnTxt         *gui.Text
+	opponentTurnTxt     *gui.Text
+	timerTxt            *gui.Text
+	timer               int
+	accuracyTxt         *gui.Text
+	startedAt           time.Time
+	endedAt             time.Time
+	lastGameStatus      string
+	shipsInfoTxt        []*gui.Text
+	customShips         []game.Coord
+	rules               game.Rules
+	autoPlayTxt         *gui.Text
+	layouts             *layout.Store
+	recorder            *replay.Recorder
+	events              *http.Stream
+	stopEvents          context.CancelFunc
+	history             *history.Store
+	sessions            *session.Store
+	pollInterval        time.Duration
+	strategy            Strategy
+	keys                *keyListener
+	updates             *uiQueue
+	inference           *boardOverlay
+	heatmap             *boardOverlay
+	lastShot            *boardOverlay
+	cursor              *cursor
+	cursorOverlay       *boardOverlay
+	cursorTxt           *gui.Text
+	lastOpponentShot    *boardOverlay
+	notice              string
+	moves               []move
+	movesTxt            []*gui.Text
+	heatmapVisible      bool
+	autoPlay            bool
+	firing              bool
+}
+
+func NewApp(client *http.Client, cfg *config.Config, logger *slog.Logger) {
 	a := App{
 		client:            client,
-		player:            nick,
-		playerDescription: description,
+		serverURL:         cfg.ServerURL,
+		logger:            logger,
+		player:            cfg.Nick,
+		playerDescription: cfg.Description,
+		pollInterval:      cfg.PollInterval.Duration(),
+	}
+	strategy, err := NewStrategy(cfg.Strategy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.rules, err = game.ParseRules(cfg.Rules)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := checkGUIRules(a.rules); err != nil {
+		log.Fatalf("%s, use -headless to play with these rules", err)
+	}
+	a.customShips, err = configFleet(a.rules, cfg.Fleet)
+	if err != nil {
+		log.Fatalf("invalid fleet: %s", err)
+	}
+	a.strategy = strategy
+	a.layouts, err = layout.DefaultStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.history, err = history.DefaultStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.sessions, err = session.DefaultStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Layout != "" {
+		a.customShips, err = loadLayout(a.layouts, a.rules, cfg.Layout)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
+	interrupts := newInterrupts()
+	gameMode := cfg.GameMode
+	ctx, done := interrupts.scope()
+	if stop, ok := a.resumeSession(ctx); ok {
+		a.playGame(ctx)
+		a.endGame()
+		stop()
+		gameMode = config.ModeMenu
+	}
+	done()
 	for {
-		targetNick, wpbot := a.displayMenu(reader, trimFunc)
-		a.newGame(a.playerDescription, a.player, targetNick, wpbot)
-		if a.lastGameStatus == "" {
-			var err error
-			makeRequest(func() error {
-				err = a.client.Abandon()
-				return err
-			})
+		var targetNick string
+		var wpbot, quit bool
+		switch gameMode {
+		case config.ModeWpbot:
+			wpbot = true
+		case config.ModeWait:
+		default:
+			ctx, done := interrupts.scope()
+			targetNick, wpbot, quit = a.displayMenu(ctx)
+			done()
 		}
+		if quit {
+			return
+		}
+		gameMode = config.ModeMenu
+		ctx, done := interrupts.scope()
+		a.newGame(ctx, a.playerDescription, a.player, targetNick, wpbot)
+		a.endGame()
+		done()
 	}
 }
 
-func (a *App) displayMenu(reader *bufio.Reader, trimFunc func(rune) bool) (string, bool) {
-	options := []string{
-		"Play against wpbot",
-		"Play against one of currently waiting players",
-		"Join waiting list",
-		"Display top 10 players stats",
-		"Display your stats",
-		"Setup your board",
+// newGame plays a single game until it ends or ctx is cancelled.
+func (a *App) newGame(ctx context.Context, description string, nick string, targetNick string, wpbot bool) {
+	title := "Waiting for an opponent..."
+	switch {
+	case wpbot:
+		title = "Starting a game against wpbot..."
+	case targetNick != "":
+		title = fmt.Sprintf("Challenging %s...", targetNick)
 	}
+	p := &page{title: title, back: "Cancel"}
+	stop, err := showWhile(ctx, p, func(ctx context.Context) error {
+		return a.startGame(ctx, description, nick, targetNick, wpbot, nil)
+	})
+	defer stop()
+	if errors.Is(err, context.Canceled) {
+		a.notify("Game cancelled")
+		return
+	}
+	if err != nil {
+		a.logger.Error("could not start the game", "error", err)
+		a.notify("Error: %s", err)
+		return
+	}
+	a.playGame(ctx)
+}
 
-	for {
-		switch getChoice(reader, trimFunc, options) {
-		case 1:
-			return "", true
-		case 2:
-			var playersList *[]http.ListResponse
-			var err error
-			makeRequest(func() error {
-				playersList, err = a.client.List()
-				return err
-			})
-			if err != nil {
-				log.Fatal(err)
-			}
-			filteredList := Filter(*playersList, func(element http.ListResponse) bool {
-				return element.GameStatus == "waiting"
-			})
-			mappedList := Map(filteredList, func(element http.ListResponse) string {
-				return element.Nick
-			})
-			chosenPlayerId := getChoice(reader, trimFunc, mappedList)
-			return mappedList[chosenPlayerId-1], false
-		case 3:
-			return "", false
-		case 4:
-			a.displayStats()
-		case 5:
-			a.displayPlayerStats()
-		case 6:
-			a.setupBoard()
-		}
+// playGame shows the started game until it ends or ctx is cancelled. Until
+// then the game is kept as the session to resume if the client stops.
+func (a *App) playGame(ctx context.Context) {
+	defer a.stopEvents()
+	a.saveSession()
+	a.run(ctx)
+}
+
+// endGame abandons the game unless it ended and forgets its session.
+func (a *App) endGame() {
+	if a.lastGameStatus == "" {
+		a.abandon()
+	}
+	a.clearSession()
+	if a.recorder != nil {
+		a.notify("Replay saved to %s", a.recorder.Path())
 	}
 }
 
-func (a *App) newGame(description string, nick string, targetNick string, wpbot bool) {
+// startGame registers a new game, waits until it begins and loads the initial
+// game state. waiting, if set, is called while the game waits for an
+// opponent.
+func (a *App) startGame(ctx context.Context, description string, nick string, targetNick string, wpbot bool, waiting func()) error {
 	a.reset()
-	var err error
-	makeRequest(func() error {
-		err = a.client.InitGame(a.customShips, description, nick, targetNick, wpbot)
-		return err
-	})
+	err := a.client.InitGame(ctx, coordStrings(a.customShips), description, nick, targetNick, wpbot)
 	if err != nil {
-		fmt.Println("Error has occurred")
-		return
+		return fmt.Errorf("could not start the game: %w", err)
 	}
 
-	var status *http.StatusResponse
-	makeRequest(func() error {
-		status, err = a.client.Status()
+	eventsCtx, stopEvents := context.WithCancel(ctx)
+	a.events = a.client.Events(eventsCtx, a.pollInterval)
+	a.stopEvents = stopEvents
+	if targetNick == "" && waiting != nil {
+		waiting()
+	}
+	err = a.waitForOpponent(ctx)
+	if err != nil {
+		stopEvents()
 		return err
-	})
+	}
+
+	board, err := a.client.Board(ctx)
 	if err != nil {
-		log.Fatal(err)
+		stopEvents()
+		return fmt.Errorf("could not fetch your board: %w", err)
+	}
+	desc, err := a.client.Description(ctx)
+	if err != nil {
+		stopEvents()
+		return fmt.Errorf("could not fetch game description: %w", err)
 	}
 
-	if targetNick == "" {
-		fmt.Println("waiting...")
+	a.player = desc.Nick
+	a.playerDescription = desc.Desc
+	a.opponent = desc.Opponent
+	a.opponentDescription = desc.OppDesc
+	a.playerShips, err = parseCoords(a.rules, board)
+	if err != nil {
+		stopEvents()
+		return fmt.Errorf("invalid board: %w", err)
 	}
+	a.game = game.New(a.rules, a.playerShips)
+	a.startedAt = time.Now()
+	a.startRecording()
 
-	counter := 0
-	for status.GameStatus != "game_in_progress" {
-		if (counter+1)%10 == 0 {
-			fmt.Println("waiting...")
-			makeRequest(func() error {
-				err := a.client.Refresh()
-				return err
-			})
-			if err != nil {
-				log.Fatal(err)
+	return nil
+}
+
+// waitForOpponent consumes events until the game starts.
+func (a *App) waitForOpponent(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-a.events.Events():
+			if !ok {
+				return fmt.Errorf("stopped waiting for the game to start")
+			}
+			switch e := e.(type) {
+			case http.OpponentJoined:
+				return nil
+			case http.StreamError:
+				return fmt.Errorf("could not wait for the game to start: %w", e.Err)
 			}
 		}
-		time.Sleep(time.Second)
-		makeRequest(func() error {
-			status, err = a.client.Status()
-			return err
-		})
-		if err != nil {
-			log.Fatal(err)
-		}
-		counter++
 	}
-	var board []string
-	makeRequest(func() error {
-		board, err = a.client.Board()
-		return err
-	})
+}
+
+func (a *App) startRecording() {
+	a.recorder = nil
+	dir, err := replay.Dir()
 	if err != nil {
-		log.Fatal(err)
+		a.logger.Warn("not recording a replay", "error", err)
+		return
 	}
-	var desc *http.DescriptionResponse
-	makeRequest(func() error {
-		desc, err = a.client.Description()
-		return err
+	a.recorder, err = replay.NewRecorder(dir, replay.Replay{
+		Player:              a.player,
+		PlayerDescription:   a.playerDescription,
+		Opponent:            a.opponent,
+		OpponentDescription: a.opponentDescription,
+		PlayerFleet:         coordStrings(a.playerShips),
+		Rules:               replayRules(a.rules),
 	})
 	if err != nil {
-		log.Fatal(err)
+		a.logger.Warn("not recording a replay", "error", err)
+		a.recorder = nil
 	}
+}
 
-	a.player = status.Nick
-	a.playerDescription = desc.Desc
-	a.opponent = status.Opponent
-	a.opponentDescription = desc.OppDesc
-	a.playerShips = board
-	a.opponentShots = status.OppShots
-	a.shouldFire = status.ShouldFire
-	a.timer = status.Timer
+// abandon gives up the current game on the server.
+func (a *App) abandon() {
+	a.logger.Info("abandoning the game", "opponent", a.opponent)
+	if err := a.client.Abandon(context.Background()); err != nil {
+		a.logger.Warn("could not abandon the game", "error", err)
+	}
+	a.recorder.End("abandoned")
+}
 
-	a.run()
+// replayRules is how rules are recorded in replays, empty for the standard
+// ones.
+func replayRules(rules game.Rules) string {
+	if rules.IsStandard() {
+		return ""
+	}
+	return rules.String()
 }
 
 func (a *App) reset() {
-	a.shotsHit = 0
-	a.shotsFired = 0
 	a.lastGameStatus = ""
+	a.shouldFire = false
+	a.turn = -1
 	a.timer = 0
-	a.opponentShips = map[int]int{
-		4: 1,
-		3: 2,
-		2: 3,
-		1: 4,
-	}
+	a.endedAt = time.Time{}
+	a.recorder = nil
+	a.moves = nil
+	a.game = game.New(a.rules, nil)
 }
 
-func (a *App) run() {
-	a.ui = gui.NewGUI(true)
-
-	a.playerBoard = gui.NewBoard(1, 8, nil)
-	a.ui.Draw(a.playerBoard)
-
-	a.playerStates = [10][10]gui.State{}
-	a.opponentStates = [10][10]gui.State{}
-	for i := range a.playerStates {
-		a.playerStates[i] = [10]gui.State{}
-		a.opponentStates[i] = [10]gui.State{}
+func (a *App) run(parent context.Context) {
+	gameTxt := a.drawGame()
 
-		for j := range a.playerStates[i] {
-			a.playerStates[i][j] = gui.Empty
-			a.opponentStates[i][j] = gui.Empty
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if a.play(ctx) {
+			a.displaySummary(ctx, gameTxt)
 		}
-	}
+		cancel()
+	}()
 
-	for _, coordinate := range a.playerShips {
-		x, y := convertCoordinate(coordinate)
-		a.playerStates[x][y] = gui.Ship
-	}
+	startGUI(ctx, a.ui)
+	cancel()
+	<-done
+}
 
-	a.playerBoard.SetStates(a.playerStates)
+// drawGame creates the GUI of the game and returns the texts the summary
+// replaces.
+func (a *App) drawGame() []*gui.Text {
+	a.ui = gui.NewGUI(true)
+	a.updates = newUIQueue()
+	a.ui.Draw(a.updates)
+
+	a.playerBoard = gui.NewBoard(1, 8, nil)
+	a.ui.Draw(a.playerBoard)
+	a.playerBoard.SetStates(boardStates(a.game.Player))
+	a.lastOpponentShot = newBoardOverlay(1, 8)
+	a.ui.Draw(a.lastOpponentShot)
 
 	a.opponentBoard = gui.NewBoard(46, 8, nil)
 	a.ui.Draw(a.opponentBoard)
-
-	exitTxt := gui.NewText(1, 1, "Press Ctrl+C to exit", &gui.TextConfig{FgColor: gui.White, BgColor: gui.Black})
+	a.inference = newBoardOverlay(46, 8)
+	a.ui.Draw(a.inference)
+	a.displayInference()
+	a.heatmap = newBoardOverlay(46, 8)
+	a.ui.Draw(a.heatmap)
+	a.lastShot = newBoardOverlay(46, 8)
+	a.ui.Draw(a.lastShot)
+	a.cursor = newCursor(a.rules)
+	a.cursorOverlay = newBoardOverlay(46, 8)
+	a.ui.Draw(a.cursorOverlay)
+	a.keys = newKeyListener()
+	a.ui.Draw(a.keys)
+	a.autoPlay = false
+
+	exitTxt := gui.NewText(1, 1, "Ctrl+C - Abandon the game (counts as a loss)", &gui.TextConfig{FgColor: gui.White, BgColor: gui.Black})
 	a.ui.Draw(exitTxt)
 	vsTxt := gui.NewText(1, 5, fmt.Sprintf("%s vs %s", a.player, a.opponent), nil)
 	a.ui.Draw(vsTxt)
@@ -242,444 +380,719 @@ func (a *App) run() {
 	hitTxt := gui.NewText(92, 10, "H - Hit", nil)
 	missTxt := gui.NewText(92, 11, "M - Miss", nil)
 	emptyTxt := gui.NewText(92, 12, "~ - Empty", nil)
-	a.ui.Draw(legendTxt)
-	a.ui.Draw(shipTxt)
-	a.ui.Draw(hitTxt)
-	a.ui.Draw(missTxt)
-	a.ui.Draw(emptyTxt)
+	ruledOutTxt := gui.NewText(92, 13, ". - Cannot contain a ship", nil)
+	certainTxt := gui.NewText(92, 14, "! - Must be a ship", nil)
+	lastShotTxt := gui.NewText(92, 15, "White field - Last shot", nil)
+	legend := []*gui.Text{legendTxt, shipTxt, hitTxt, missTxt, emptyTxt, ruledOutTxt, certainTxt, lastShotTxt}
+	for _, txt := range legend {
+		a.ui.Draw(txt)
+	}
+	keysY := 20 + len(a.rules.Fleet.Lengths())
+	if keysY < 24 {
+		keysY = 24
+	}
+	assistKeysTxt := []*gui.Text{
+		gui.NewText(92, keysY, "Keys:", nil),
+		gui.NewText(92, keysY+1, "a - Fire suggested shot", nil),
+		gui.NewText(92, keysY+2, "P - Toggle auto-play", nil),
+		gui.NewText(92, keysY+3, "M - Toggle heatmap", nil),
+		gui.NewText(92, keysY+4, "Arrows/hjkl - Move cursor", nil),
+		gui.NewText(92, keysY+5, "Enter/Space - Fire at cursor", nil),
+		gui.NewText(92, keysY+6, "E5 - Move cursor to E5", nil),
+	}
+	for _, txt := range assistKeysTxt {
+		a.ui.Draw(txt)
+	}
+	a.cursorTxt = gui.NewText(92, keysY+8, "", nil)
+	a.ui.Draw(a.cursorTxt)
+	a.displayCursor()
+	a.autoPlayTxt = gui.NewText(92, keysY+9, "", nil)
+	a.ui.Draw(a.autoPlayTxt)
+	a.ui.Draw(gui.NewText(92, keysY+11, "Moves:", nil))
+	a.movesTxt = nil
+	for i := 0; i < movesShown; i++ {
+		txt := gui.NewText(92, keysY+12+i, "", nil)
+		a.movesTxt = append(a.movesTxt, txt)
+		a.ui.Draw(txt)
+	}
 	a.yourTurnTxt = gui.NewText(46, 5, "Your turn!", &gui.TextConfig{FgColor: gui.White, BgColor: gui.Green})
 	a.opponentTurnTxt = gui.NewText(46, 5, "Opponent turn!", &gui.TextConfig{FgColor: gui.White, BgColor: gui.Red})
 	a.displayTurnInfo()
 	a.timerTxt = gui.NewText(46, 3, "", nil)
 	a.ui.Draw(a.timerTxt)
-	a.accuracyTxt = gui.NewText(46, 1, fmt.Sprintf("Accuracy: %d/%d", a.shotsHit, a.shotsFired), nil)
+	a.accuracyTxt = gui.NewText(46, 1, fmt.Sprintf("Accuracy: %d/%d", a.game.ShotsHit, a.game.ShotsFired), nil)
 	a.ui.Draw(a.accuracyTxt)
-	shipsInfoTxt := gui.NewText(92, 15, "Remaining opponent ships:", nil)
-	a.fourTileShipsInfoTxt = gui.NewText(92, 16, "4 tile: 1/1", nil)
-	a.threeTileShipsInfoTxt = gui.NewText(92, 17, "3 tile: 2/2", nil)
-	a.twoTileShipsInfoTxt = gui.NewText(92, 18, "2 tile: 3/3", nil)
-	a.oneTileShipsInfoTxt = gui.NewText(92, 19, "1 tile: 4/4", nil)
+	shipsInfoTxt := gui.NewText(92, 17, "Remaining opponent ships:", nil)
 	a.ui.Draw(shipsInfoTxt)
-	a.ui.Draw(a.fourTileShipsInfoTxt)
-	a.ui.Draw(a.threeTileShipsInfoTxt)
-	a.ui.Draw(a.twoTileShipsInfoTxt)
-	a.ui.Draw(a.oneTileShipsInfoTxt)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	go func(ctx context.Context) {
-		a.waitForYourTurn()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				a.displayTurnInfo()
-				a.handleFire(ctx)
-				a.displayTurnInfo()
-				a.waitForYourTurn()
-			}
-		}
-	}(ctx)
-
-	a.cancelFunc = cancel
+	a.shipsInfoTxt = nil
+	for i := range a.rules.Fleet.Lengths() {
+		txt := gui.NewText(92, 18+i, "", nil)
+		a.shipsInfoTxt = append(a.shipsInfoTxt, txt)
+		a.ui.Draw(txt)
+	}
+	a.displayShipsInfo()
+	a.displayMoves()
 
-	a.ui.Start(context.Background(), nil)
+	// The summary replaces the texts that only make sense during the game.
+	gameTxt := []*gui.Text{exitTxt, shipsInfoTxt}
+	gameTxt = append(gameTxt, legend...)
+	return append(gameTxt, assistKeysTxt...)
 }
 
-func (a *App) drawOppShots() {
-	for _, coord := range a.opponentShots {
-		x, y := convertCoordinate(coord)
-		if a.playerStates[x][y] == gui.Hit || a.playerStates[x][y] == gui.Miss {
-			continue
+// handleEvent applies a game event to the game state.
+func (a *App) handleEvent(e http.Event) {
+	switch e := e.(type) {
+	case http.OpponentShot:
+		c, err := a.rules.ParseCoord(e.Coord)
+		if err != nil {
+			a.logger.Warn("invalid opponent shot", "coord", e.Coord, "error", err)
+			return
 		}
-		if a.playerStates[x][y] == gui.Ship {
-			a.playerStates[x][y] = gui.Hit
-		} else {
-			a.playerStates[x][y] = gui.Miss
+		a.opponentShot(c)
+	case http.YourTurn:
+		// A turn reported again after it ended is stale.
+		if e.Turn <= a.turn {
+			return
+		}
+		a.turn = e.Turn
+		a.shouldFire = true
+	case http.TimerTick:
+		a.timer = e.Remaining
+	case http.StreamError:
+		a.logger.Error("lost track of the game", "error", e.Err)
+	case http.GameEnded:
+		if a.lastGameStatus == "" {
+			a.logger.Info("game ended", "result", e.Result, "opponent", a.opponent)
+			a.lastGameStatus = e.Result
+			a.endedAt = time.Now()
+			a.recorder.End(e.Result)
+			a.saveHistory()
 		}
 	}
-	a.playerBoard.SetStates(a.playerStates)
 }
 
-func (a *App) displayTurnInfo() {
-	a.ui.Remove(a.yourTurnTxt)
-	a.ui.Remove(a.opponentTurnTxt)
-	if a.shouldFire {
-		a.ui.Draw(a.yourTurnTxt)
-	} else {
-		a.ui.Draw(a.opponentTurnTxt)
-	}
-}
-
-func (a *App) waitForYourTurn() {
-	for !a.shouldFire {
-		time.Sleep(time.Second)
-		var status *http.StatusResponse
-		var err error
-		makeRequest(func() error {
-			status, err = a.client.Status()
-			return err
-		})
-		if err != nil {
-			return
-		}
+// fireResult is the outcome of a shot fired in the background.
+type fireResult struct {
+	coord    game.Coord
+	turn     int
+	response *http.FireResponse
+	err      error
+	// status is fetched when the server refuses the shot, to tell why.
+	status *http.StatusResponse
+}
 
-		a.shouldFire = status.ShouldFire
-		a.opponentShots = status.OppShots
-		a.drawOppShots()
-		a.timer = status.Timer
+// play runs the game until it ends or ctx is done and reports whether the
+// game is over, either finished or no longer available. It is the only goroutine
+// that changes the game state while the GUI runs: game events, key presses,
+// clicks and shot results are all delivered to it over channels, and shots
+// are fired in the background so the timer keeps running meanwhile.
+func (a *App) play(ctx context.Context) bool {
+	clicks := make(chan game.Coord)
+	go listenClicks(ctx, a.opponentBoard, clicks)
+	results := make(chan fireResult, 1)
+	var autoShot <-chan time.Time
 
-		if status.GameStatus == "ended" {
-			a.lastGameStatus = status.LastGameStatus
-			a.handleGameEnded()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case e, ok := <-a.events.Events():
+			if !ok {
+				return false
+			}
+			if _, ended := e.(http.GameEnded); ended && a.firing {
+				// The shot that ended the game may still be on its way,
+				// it's recorded first so the game is saved complete.
+				select {
+				case r := <-results:
+					a.firing = false
+					a.handleFireResult(r)
+				case <-ctx.Done():
+					return false
+				}
+			}
+			a.handleEvent(e)
+			switch e.(type) {
+			case http.OpponentShot:
+				a.setStates(a.playerBoard, boardStates(a.game.Player))
+				a.displayMoves()
+			case http.TimerTick:
+				a.setText(a.timerTxt, strconv.Itoa(a.timer))
+			case http.YourTurn:
+				a.displayTurnInfo()
+				a.displayHeatmap()
+			case http.GameEnded, http.StreamError:
+				return true
+			}
+		case e := <-a.keys.ch:
+			if used, selected := a.cursor.handle(e); used {
+				if selected {
+					a.fire(ctx, a.cursor.at, results)
+				}
+				a.displayCursor()
+				continue
+			}
+			switch e.Ch {
+			case 'a':
+				a.fireSuggested(ctx, results)
+			case 'p', 'P':
+				a.autoPlay = !a.autoPlay
+				if a.autoPlay {
+					a.setText(a.autoPlayTxt, "Auto-play on")
+				} else {
+					a.setText(a.autoPlayTxt, "")
+				}
+			case 'm', 'M':
+				a.heatmapVisible = !a.heatmapVisible
+				a.displayHeatmap()
+			}
+		case coord := <-clicks:
+			a.cursor.at = coord
+			a.displayCursor()
+			a.fire(ctx, coord, results)
+		case <-autoShot:
+			autoShot = nil
+			if a.autoPlay {
+				a.fireSuggested(ctx, results)
+			}
+		case r := <-results:
+			a.firing = false
+			if !a.handleFireResult(r) {
+				return true
+			}
+		}
+
+		if a.autoPlay && a.shouldFire && !a.firing && autoShot == nil {
+			autoShot = time.After(500 * time.Millisecond)
 		}
 	}
 }
 
-func (a *App) handleFire(mainCtx context.Context) {
-	var result string
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	a.resetTimer(&a.timer, ctx)
-	for result != "miss" {
-		coordinate := a.opponentBoard.Listen(mainCtx)
-		if coordinate == "" {
-			break
-		}
-		x, y := convertCoordinate(coordinate)
-		if a.opponentStates[x][y] == gui.Hit || a.opponentStates[x][y] == gui.Miss {
-			continue
-		}
-		var fireResponse *http.FireResponse
-		var err error
-		makeRequest(func() error {
-			fireResponse, err = a.client.Fire(coordinate)
-			return err
-		})
-		if err != nil {
-			break
-		}
-		a.shotsFired++
-		if fireResponse.Result == "miss" {
-			a.opponentStates[x][y] = gui.Miss
-		} else {
-			a.opponentStates[x][y] = gui.Hit
-			a.shotsHit++
-			a.timerTxt.SetText("60")
-			a.timer = 60
-			if fireResponse.Result == "sunk" {
-				a.handleSunk(coordinate)
-			}
-		}
-		a.opponentBoard.SetStates(a.opponentStates)
-		a.accuracyTxt.SetText(fmt.Sprintf("Accuracy: %d/%d", a.shotsHit, a.shotsFired))
+// canFire reports whether a shot can be fired now: it's our turn and no shot
+// is on its way.
+func (a *App) canFire() bool {
+	return a.shouldFire && !a.firing && !a.game.FleetSunk()
+}
 
-		if fireResponse.Result == "sunk" {
+// fire sends a shot at c in the background unless it's not our turn, a shot
+// is already on its way or the field was already shot.
+func (a *App) fire(ctx context.Context, c game.Coord, results chan<- fireResult) {
+	if !a.canFire() || a.game.Opponent.At(c) != game.Empty {
+		return
+	}
+	a.firing = true
+	turn := a.turn
+	go func() {
+		response, err := a.client.Fire(ctx, c.String())
+		r := fireResult{coord: c, turn: turn, response: response, err: err}
+		if errors.Is(err, http.ErrBadRequest) {
+			r.status, _ = a.client.Status(ctx)
+		}
+		results <- r
+	}()
+}
 
-			var status *http.StatusResponse
-			makeRequest(func() error {
-				status, err = a.client.Status()
-				return err
-			})
-			if err != nil {
-				log.Fatal(err)
-			}
+// fireSuggested fires at the field picked by the strategy. The strategy is
+// only asked when a shot can be fired, as it may take a while to decide.
+func (a *App) fireSuggested(ctx context.Context, results chan<- fireResult) {
+	if !a.canFire() {
+		return
+	}
+	if c, ok := a.strategy.NextShot(a.game.Opponent, a.game.Remaining); ok {
+		a.fire(ctx, c, results)
+	}
+}
 
-			if status.GameStatus == "ended" {
-				a.lastGameStatus = status.LastGameStatus
-				cancel()
-				a.handleGameEnded()
-			}
+// handleFireResult applies the result of a shot. It returns false if the
+// game can't be continued.
+func (a *App) handleFireResult(r fireResult) bool {
+	if errors.Is(r.err, http.ErrNotFound) || errors.Is(r.err, http.ErrUnauthorized) {
+		a.logger.Error("game is no longer available", "error", r.err)
+		return false
+	}
+	if errors.Is(r.err, http.ErrBadRequest) {
+		a.logger.Warn("shot refused", "coord", r.coord, "error", r.err)
+		if tooLate(r.status) {
+			a.endTurn(r.turn)
 		}
+		return true
+	}
+	if r.err != nil {
+		a.logger.Warn("shot failed", "coord", r.coord, "error", r.err)
+		return true
+	}
 
-		result = fireResponse.Result
+	a.recordShot(r.coord, r.response.Result)
+	a.saveSession()
+	if r.response.Result == "sunk" {
+		a.displayShipsInfo()
+	}
+	a.setStates(a.opponentBoard, boardStates(a.game.Opponent))
+	a.displayInference()
+	a.displayMoves()
+	a.setText(a.accuracyTxt, fmt.Sprintf("Accuracy: %d/%d", a.game.ShotsHit, a.game.ShotsFired))
+
+	if r.response.Result == "miss" {
+		a.endTurn(r.turn)
+		return true
 	}
+	a.timer = 60
+	a.setText(a.timerTxt, "60")
+	a.events.Poll()
+	a.displayHeatmap()
+	return true
+}
 
-	cancel()
+// tooLate reports whether status, fetched after the server refused a shot,
+// shows the shot came after our turn or the game had ended. The server
+// answers 400 to both without telling them apart from an invalid shot.
+func tooLate(status *http.StatusResponse) bool {
+	return status != nil && (status.GameStatus != "game_in_progress" || !status.ShouldFire)
+}
+
+// endTurn ends the turn a shot was fired in. The stream may have announced
+// the next turn while the shot was on its way, that one goes on.
+func (a *App) endTurn(turn int) {
+	if turn != a.turn {
+		return
+	}
 	a.shouldFire = false
+	a.events.Poll()
+	a.displayTurnInfo()
+	a.heatmap.Clear()
 }
 
-func (a *App) handleGameEnded() {
-	var resultTxt gui.Drawable
-	if a.lastGameStatus == "win" {
-		resultTxt = gui.NewText(46, 3, "You won!", &gui.TextConfig{FgColor: gui.Black, BgColor: gui.Green})
-	} else {
-		resultTxt = gui.NewText(46, 3, "You lost!", &gui.TextConfig{FgColor: gui.Black, BgColor: gui.Red})
+// listenClicks forwards the fields clicked on board to clicks until ctx is
+// done.
+func listenClicks(ctx context.Context, board *gui.Board, clicks chan<- game.Coord) {
+	for {
+		coord := board.Listen(ctx)
+		if coord == "" {
+			return
+		}
+		c, err := game.ParseCoord(coord)
+		if err != nil {
+			continue
+		}
+		select {
+		case clicks <- c:
+		case <-ctx.Done():
+			return
+		}
 	}
-	a.ui.Remove(a.opponentTurnTxt)
-	a.ui.Remove(a.yourTurnTxt)
-	a.ui.Draw(resultTxt)
-	a.cancelFunc()
 }
 
-func (a *App) displayStats() {
-	var stats *http.StatsResponse
-	var err error
-	makeRequest(func() error {
-		stats, err = a.client.Stats()
-		return err
+// saveHistory appends the finished game to the local match history.
+func (a *App) saveHistory() {
+	if a.history == nil {
+		return
+	}
+	err := a.history.Append(history.Entry{
+		Player:     a.player,
+		Opponent:   a.opponent,
+		Result:     a.lastGameStatus,
+		ShotsFired: a.game.ShotsFired,
+		ShotsHit:   a.game.ShotsHit,
+		Turns:      a.game.Turns,
+		Duration:   a.endedAt.Sub(a.startedAt).Round(time.Second),
+		StartedAt:  a.startedAt,
+		EndedAt:    a.endedAt,
 	})
 	if err != nil {
-		log.Fatal(err)
+		a.logger.Warn("could not save the game to history", "error", err)
 	}
+}
 
-	fmt.Println()
-	fmt.Printf("| %s | %-20s | %s | %s | %s |\n", "RANK", "NICK", "GAMES", "WINS", "POINTS")
-	for _, s := range stats.Stats {
-		fmt.Printf("| %4d | %-20s | %5d | %4d | %6d |\n",
-			s.Rank,
-			s.Nick,
-			s.Games,
-			s.Wins,
-			s.Points,
-		)
-	}
-	fmt.Println()
+func (a *App) displayTurnInfo() {
+	shouldFire := a.shouldFire
+	a.updates.Do(func() {
+		a.ui.Remove(a.yourTurnTxt)
+		a.ui.Remove(a.opponentTurnTxt)
+		if shouldFire {
+			a.ui.Draw(a.yourTurnTxt)
+		} else {
+			a.ui.Draw(a.opponentTurnTxt)
+		}
+	})
 }
 
-func (a *App) displayPlayerStats() {
-	var stats *http.PlayerStatsResponse
-	var err error
-	makeRequest(func() error {
-		stats, err = a.client.PlayerStats(a.player)
-		return err
+func (a *App) setText(txt *gui.Text, text string) {
+	a.updates.Do(func() {
+		txt.SetText(text)
 	})
-	if err != nil {
-		log.Fatal(err)
-	}
+}
 
-	fmt.Println()
-	fmt.Printf("| %s | %-20s | %s | %s | %s |\n", "RANK", "NICK", "GAMES", "WINS", "POINTS")
-	fmt.Printf("| %4d | %-20s | %5d | %4d | %6d |\n",
-		stats.Stats.Rank,
-		stats.Stats.Nick,
-		stats.Stats.Games,
-		stats.Stats.Wins,
-		stats.Stats.Points,
-	)
+func (a *App) setStates(board *gui.Board, states [10][10]gui.State) {
+	a.updates.Do(func() {
+		board.SetStates(states)
+	})
+}
 
-	fmt.Println()
+func (a *App) displayCursor() {
+	a.cursorOverlay.SetCells(a.cursor.cells())
+	a.setText(a.cursorTxt, a.cursor.label())
 }
 
-func (a *App) resetTimer(timer *int, ctx context.Context) {
-	if timer != nil {
-		a.timer = *timer
-	} else {
-		a.timer = 60
+// displayInference shades the opponent's fields that cannot contain a ship
+// and marks the ones that must be a ship.
+func (a *App) displayInference() {
+	cells := make(map[game.Coord]overlayCell)
+	for _, c := range a.game.RuledOut {
+		cells[c] = overlayCell{char: '.', fg: gui.White, bg: gui.Grey}
 	}
-	a.timerTxt.SetText(strconv.Itoa(a.timer))
-	go func(context context.Context) {
-		for {
-			select {
-			case <-context.Done():
-				a.timerTxt.SetText("")
-				return
-			default:
-				time.Sleep(time.Second)
-				if a.timer > 0 {
-					a.timer--
-				}
-				a.timerTxt.SetText(strconv.Itoa(a.timer))
+	for _, c := range a.game.Certain {
+		cells[c] = overlayCell{char: '!', fg: gui.Black, bg: gui.Green}
+	}
+	a.inference.SetCells(cells)
+}
+
+func (a *App) displayHeatmap() {
+	if !a.heatmapVisible || !a.shouldFire {
+		a.heatmap.Clear()
+		return
+	}
+
+	density := Density(a.game.Opponent, a.game.Remaining)
+	maxDensity := 0.0
+	for x := range density {
+		for y := range density[x] {
+			if density[x][y] > maxDensity {
+				maxDensity = density[x][y]
 			}
 		}
-	}(ctx)
+	}
 
+	cells := make(map[game.Coord]overlayCell)
+	for _, c := range a.game.Opponent.Find(game.Empty) {
+		if maxDensity == 0 {
+			break
+		}
+		level := density[c.X][c.Y] / maxDensity
+		cells[c] = overlayCell{
+			char: byte('0' + int(level*9)),
+			fg:   gui.Black,
+			bg:   blend(gui.Blue, gui.Red, level),
+		}
+	}
+	if suggestion, ok := a.strategy.NextShot(a.game.Opponent, a.game.Remaining); ok {
+		cells[suggestion] = overlayCell{char: '*', fg: gui.Black, bg: gui.White}
+	}
+	a.heatmap.SetCells(cells)
 }
 
-func (a *App) handleSunk(coord string) {
-	ship := getShip(a.opponentStates, coord)
-	setImpossiblePositions(&a.opponentStates, ship)
-	a.opponentShips[len(ship)]--
-	a.fourTileShipsInfoTxt.SetText(fmt.Sprintf("4 tile: %d/1", a.opponentShips[4]))
-	a.threeTileShipsInfoTxt.SetText(fmt.Sprintf("3 tile: %d/2", a.opponentShips[3]))
-	a.twoTileShipsInfoTxt.SetText(fmt.Sprintf("2 tile: %d/3", a.opponentShips[2]))
-	a.oneTileShipsInfoTxt.SetText(fmt.Sprintf("1 tile: %d/4", a.opponentShips[1]))
+// recordShot updates the game with the result of firing at c.
+func (a *App) recordShot(c game.Coord, result string) {
+	a.recorder.Shot(c.String(), result)
+	a.game.Shot(c, game.Result(result))
+	a.moves = append(a.moves, move{coord: c, result: game.Result(result)})
 }
 
-func (a *App) setupBoard() {
-	ships := map[int]int{
-		4: 1,
-		3: 2,
-		2: 3,
-		1: 4,
+func (a *App) displayShipsInfo() {
+	remaining := a.game.Remaining
+	for i, length := range a.rules.Fleet.Lengths() {
+		a.setText(a.shipsInfoTxt[i], fmt.Sprintf("%d tile: %d/%d", length, remaining[length], a.rules.Fleet[length]))
 	}
-	var shipsCoord []string
-	ui := gui.NewGUI(true)
-	board := gui.NewBoard(1, 5, nil)
-	states := [10][10]gui.State{}
-	for i := range states {
-		states[i] = [10]gui.State{}
-		for j := range states[i] {
-			states[i][j] = gui.Empty
-		}
+}
+
+// setupBoard lets the player place their ships. It returns without changing
+// the layout when ctx is done.
+func (a *App) setupBoard(parent context.Context) {
+	ships, err := fleetShips(a.rules, a.customShips)
+	if err != nil {
+		ships = nil
 	}
+	sizes := a.rules.Ships()
+	var current []game.Coord
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	ui := gui.NewGUI(true)
+	updates := newUIQueue()
+	ui.Draw(updates)
+	board := gui.NewBoard(1, 7, nil)
 	ui.Draw(board)
-	board.SetStates(states)
+	cur := newCursor(a.rules)
+	cursorOverlay := newBoardOverlay(1, 7)
+	ui.Draw(cursorOverlay)
 	placedShipTxt := gui.NewText(1, 1, "", nil)
 	ui.Draw(placedShipTxt)
-	impossibleInfoTxt := gui.NewText(1, 3, "If you cannot place ship press Ctrl + C and try setting up board once again!", nil)
-	ui.Draw(impossibleInfoTxt)
-	ctx, cancel := context.WithCancel(context.Background())
+	infoTxt := gui.NewText(1, 3, "", nil)
+	ui.Draw(infoTxt)
+	keysTxt := []string{
+		"Keys:",
+		"Arrows/hjkl - Move cursor",
+		"Enter/Space - Place at cursor",
+		"E5 - Move cursor to E5",
+		"u - Undo",
+		"r - Random",
+		"e - Random avoiding edges",
+		"s - Random spread out",
+	}
+	for i, line := range keysTxt {
+		ui.Draw(gui.NewText(50, 9+i, line, nil))
+	}
+	cursorTxt := gui.NewText(50, 10+len(keysTxt), "", nil)
+	ui.Draw(cursorTxt)
+	keys := newKeyListener()
+	ui.Draw(keys)
+	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 
+	clicks := make(chan game.Coord)
+	go listenClicks(ctx, board, clicks)
+
+	done := make(chan struct{})
 	go func() {
-		for shipLength := 4; shipLength > 0; shipLength-- {
-			shipsCount := ships[shipLength]
-			for i := 0; i < shipsCount; i++ {
-				var ship []string
-				placedShipTxt.SetText(fmt.Sprintf("Place ship of length %d", shipLength))
-
-				for i := range states {
-					for j := range states[i] {
-						if states[i][j] == gui.Empty {
-							states[i][j] = gui.Hit
-						}
-					}
-				}
+		defer close(done)
+		for {
+			states, possible := setupStates(a.rules, ships, current)
+			placed := len(ships) == len(sizes)
+			var placedShip, info string
+			switch {
+			case placed:
+				placedShip = "All ships placed! Press Ctrl + C to save and exit"
+			case !possible:
+				placedShip = fmt.Sprintf("Place ship of length %d", sizes[len(ships)])
+				info = "There is no room for this ship! Press U to undo or R to randomize"
+			default:
+				placedShip = fmt.Sprintf("Place ship of length %d", sizes[len(ships)])
+			}
+			cursorOverlay.SetCells(cur.cells())
+			cursorLabel := cur.label()
+			updates.Do(func() {
 				board.SetStates(states)
+				placedShipTxt.SetText(placedShip)
+				infoTxt.SetText(info)
+				cursorTxt.SetText(cursorLabel)
+			})
 
-				for i := 0; i < shipLength; i++ {
-					for {
-						coord := board.Listen(ctx)
-						if coord == "" {
-							return
-						}
-						x, y := convertCoordinate(coord)
-
-						if states[x][y] == gui.Hit {
-							ship = append(ship, coord)
-							states[x][y] = gui.Ship
-							for j := range states {
-								for k := range states[i] {
-									if states[j][k] == gui.Hit {
-										states[j][k] = gui.Empty
-									}
-								}
-							}
-							setPossiblePositions(&states, ship)
-							board.SetStates(states)
-							break
-						}
-					}
+			var selected game.Coord
+			select {
+			case <-ctx.Done():
+				return
+			case selected = <-clicks:
+				cur.at = selected
+			case e := <-keys.ch:
+				used, ok := cur.handle(e)
+				if !used {
+					ships, current = setupCommand(r, a.rules, e.Ch, ships, current)
 				}
-
-				for i := range states {
-					for j := range states[i] {
-						if states[i][j] == gui.Hit {
-							states[i][j] = gui.Empty
-						}
-					}
+				if !ok {
+					continue
 				}
-				shipsCoord = append(shipsCoord, ship...)
-				setImpossiblePositions(&states, Map(ship, func(element string) point {
-					x, y := convertCoordinate(element)
-					return point{x, y}
-				}))
-				board.SetStates(states)
+				selected = cur.at
+			}
+
+			if placed || states[selected.X][selected.Y] != gui.Hit {
+				continue
+			}
+			current = append(current, selected)
+			if len(current) == sizes[len(ships)] {
+				ships = append(ships, current)
+				current = nil
 			}
 		}
-		a.customShips = shipsCoord
-		placedShipTxt.SetText("Ships saved! Press Ctrl + C to exit")
-		ui.Remove(impossibleInfoTxt)
 	}()
 
-	ui.Start(ctx, nil)
+	startGUI(ctx, ui)
+	cancel()
+	<-done
+
+	if parent.Err() != nil {
+		return
+	}
+	if len(ships) != len(sizes) {
+		a.notify("Board setup was not finished, keeping the previous layout")
+		return
+	}
+	var coords []game.Coord
+	for _, ship := range ships {
+		coords = append(coords, ship...)
+	}
+	a.customShips = coords
+	a.notify("Ships saved!")
 }
 
-type point struct {
-	x int
-	y int
+// setupCommand applies a board setup key command to the placed ships and the
+// ship being placed.
+func setupCommand(r *rand.Rand, rules game.Rules, ch rune, ships [][]game.Coord, current []game.Coord) ([][]game.Coord, []game.Coord) {
+	var opts fleet.Options
+	switch ch {
+	case 'u', 'U':
+		if len(current) > 0 {
+			return ships, nil
+		}
+		if len(ships) > 0 {
+			return ships[:len(ships)-1], nil
+		}
+		return ships, current
+	case 'r', 'R':
+	case 'e':
+		opts.AvoidEdges = true
+	case 's', 'S':
+		opts.SpreadOut = true
+	default:
+		return ships, current
+	}
+	if random, err := randomShips(r, rules, opts); err == nil {
+		return random, nil
+	}
+	return ships, current
 }
 
-func getShip(board [10][10]gui.State, coord string) []point {
-	var ship []point
-	x, y := convertCoordinate(coord)
-	toVisit := []point{{x, y}}
-	visited := make(map[point]struct{})
+func randomShips(r *rand.Rand, rules game.Rules, opts fleet.Options) ([][]game.Coord, error) {
+	random, err := fleet.Random(r, rules, opts)
+	if err != nil {
+		return nil, err
+	}
+	coords, err := parseCoords(rules, random)
+	if err != nil {
+		return nil, err
+	}
+	return fleetShips(rules, coords)
+}
 
-	offsets := []point{
-		{1, 0},
-		{0, 1},
-		{-1, 0},
-		{0, -1},
+// fleetShips validates coords and groups them into ships, longest first.
+func fleetShips(rules game.Rules, coords []game.Coord) ([][]game.Coord, error) {
+	ships, err := fleet.Ships(rules, coordStrings(coords))
+	if err != nil {
+		return nil, err
 	}
+	return Map(ships, func(ship []string) []game.Coord {
+		coords, _ := parseCoords(rules, ship)
+		return coords
+	}), nil
+}
 
-	for len(toVisit) > 0 {
-		current := toVisit[0]
-		toVisit = toVisit[1:]
-		if board[current.x][current.y] == gui.Hit {
-			for _, offset := range offsets {
-				checked := point{current.x + offset.x, current.y + offset.y}
-				if _, exists := visited[checked]; exists || checked.x < 0 || checked.x >= 10 || checked.y < 0 || checked.y >= 10 {
-					continue
-				}
-				toVisit = append(toVisit, checked)
-			}
-			ship = append(ship, current)
-		}
-		visited[current] = struct{}{}
+func loadLayout(layouts *layout.Store, rules game.Rules, name string) ([]game.Coord, error) {
+	coords, err := layouts.Load(name, rules)
+	if err != nil {
+		return nil, err
 	}
-	return ship
+	return parseCoords(rules, coords)
 }
 
-func setImpossiblePositions(board *[10][10]gui.State, ship []point) {
-	offsets := []point{
-		{1, 0},
-		{0, 1},
-		{1, 1},
-		{-1, 0},
-		{0, -1},
-		{-1, -1},
-		{1, -1},
-		{-1, 1},
+// checkGUIRules reports rules the GUI cannot show, as its boards are always
+// 10x10.
+func checkGUIRules(rules game.Rules) error {
+	if rules.Width != game.Size || rules.Height != game.Size {
+		return fmt.Errorf("the GUI only supports %dx%d boards, not %dx%d", game.Size, game.Size, rules.Width, rules.Height)
 	}
+	return nil
+}
 
-	for _, current := range ship {
-		for _, offset := range offsets {
-			checked := point{current.x + offset.x, current.y + offset.y}
-			if checked.x < 0 || checked.x >= 10 || checked.y < 0 || checked.y >= 10 {
-				continue
+// setupStates renders placed ships, the ship being placed and the fields where
+// the next part of it can go. Fields next to placed ships are marked as misses
+// and possible fields as hits. It also reports if any possible field exists.
+func setupStates(rules game.Rules, ships [][]game.Coord, current []game.Coord) ([10][10]gui.State, bool) {
+	sizes := rules.Ships()
+	board := game.NewBoard(rules.Width, rules.Height)
+	for _, ship := range ships {
+		for _, c := range ship {
+			board.Set(c, game.Occupied)
+		}
+	}
+	for _, ship := range ships {
+		board.MarkAround(ship)
+	}
+	if len(ships) == len(sizes) {
+		return boardStates(board), false
+	}
+
+	inCurrent := make(map[game.Coord]bool)
+	for _, c := range current {
+		board.Set(c, game.Occupied)
+		inCurrent[c] = true
+	}
+
+	possible := false
+	for _, placement := range board.Placements(sizes[len(ships)]) {
+		covered := 0
+		free := true
+		for _, c := range placement {
+			if inCurrent[c] {
+				covered++
+			} else if cell := board.At(c); cell != game.Empty && cell != game.Hit {
+				free = false
 			}
-			if board[checked.x][checked.y] == gui.Empty {
-				board[checked.x][checked.y] = gui.Miss
+		}
+		if !free || covered != len(current) {
+			continue
+		}
+		for _, c := range placement {
+			if inCurrent[c] || !nextTo(c, inCurrent) {
+				continue
 			}
+			board.Set(c, game.Hit)
+			possible = true
 		}
 	}
+
+	return boardStates(board), possible
 }
 
-func setPossiblePositions(board *[10][10]gui.State, ship []string) {
-	offsets := []point{
-		{1, 0},
-		{0, 1},
-		{-1, 0},
-		{0, -1},
+func nextTo(c game.Coord, cells map[game.Coord]bool) bool {
+	if len(cells) == 0 {
+		return true
 	}
+	for _, offset := range game.SideOffsets {
+		if cells[c.Add(offset)] {
+			return true
+		}
+	}
+	return false
+}
 
-	for _, coord := range ship {
-		x, y := convertCoordinate(coord)
-		for _, offset := range offsets {
-			checked := point{x + offset.x, y + offset.y}
-			if checked.x < 0 || checked.x >= 10 || checked.y < 0 || checked.y >= 10 {
-				continue
-			}
-			if board[checked.x][checked.y] == gui.Empty {
-				board[checked.x][checked.y] = gui.Hit
+// boardStates converts a board to the states the GUI draws.
+func boardStates(board *game.Board) [10][10]gui.State {
+	var states [10][10]gui.State
+	for x := range states {
+		for y := range states[x] {
+			switch board.At(game.Coord{X: x, Y: y}) {
+			case game.Occupied:
+				states[x][y] = gui.Ship
+			case game.Hit, game.Sunk:
+				states[x][y] = gui.Hit
+			case game.Miss:
+				states[x][y] = gui.Miss
+			default:
+				states[x][y] = gui.Empty
 			}
 		}
 	}
+	return states
 }
 
-func convertCoordinate(coordinate string) (int, int) {
-	if len(coordinate) < 2 || len(coordinate) > 3 {
-		log.Fatal("coordinate should have length 2 or 3!")
+// coordStrings formats coords for the server. No coords give nil, which lets
+// the server place the ships.
+func coordStrings(coords []game.Coord) []string {
+	if len(coords) == 0 {
+		return nil
 	}
+	return Map(coords, game.Coord.String)
+}
 
-	if len(coordinate) == 2 {
-		return int(coordinate[0]) - 65, int(coordinate[1]) - 49
+// configFleet checks the fleet set in the config and parses it. No fleet
+// lets the server place the ships.
+func configFleet(rules game.Rules, coords []string) ([]game.Coord, error) {
+	if len(coords) == 0 {
+		return nil, nil
 	}
+	if err := fleet.Validate(rules, coords); err != nil {
+		return nil, err
+	}
+	return parseCoords(rules, coords)
+}
 
-	return int(coordinate[0]) - 65, 9
+func parseCoords(rules game.Rules, coords []string) ([]game.Coord, error) {
+	parsed := make([]game.Coord, 0, len(coords))
+	for _, coord := range coords {
+		c, err := rules.ParseCoord(coord)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, c)
+	}
+	return parsed, nil
 }
 
 func wrapText(text string) []string {
@@ -699,26 +1112,3 @@ func wrapText(text string) []string {
 
 	return lines
 }
-
-func getChoice(reader *bufio.Reader, trimFunc func(rune) bool, options []string) int {
-	for i, option := range options {
-		fmt.Printf("%d. %s\n", i+1, option)
-	}
-
-	for {
-		choiceInput, err := reader.ReadString('\n')
-		if err != nil {
-			log.Fatal(err)
-		}
-		choice, err := strconv.Atoi(strings.TrimRightFunc(choiceInput, trimFunc))
-		if err != nil {
-			fmt.Println("Your choice must be an integer number! Try again")
-			continue
-		}
-		if choice < 1 || choice > len(options) {
-			fmt.Println("You must choose from existing options! Try again")
-			continue
-		}
-		return choice
-	}
-}
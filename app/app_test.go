@@ -0,0 +1,174 @@
+package app
+
+import (
+	"battleship-client/fakeserver"
+	"battleship-client/game"
+	"battleship-client/history"
+	"battleship-client/http"
+	"battleship-client/logging"
+	"battleship-client/replay"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tl "github.com/grupawp/termloop"
+)
+
+// TestPlayFullGame plays whole games in a row against wpbot through the GUI
+// game loop, firing the suggested shot on every key press, without starting
+// the terminal. Run it with -race: the loop must be the only goroutine
+// changing the game state.
+func TestPlayFullGame(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	ts := fakeserver.NewTestServer(fakeserver.Options{Seed: 1})
+	defer ts.Close()
+
+	strategy, err := NewStrategy("density")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{
+		client:       http.NewClient(ts.URL+fakeserver.APIPrefix, 5*time.Second),
+		logger:       logging.Discard(),
+		player:       "tester",
+		rules:        game.StandardRules(),
+		history:      history.NewStore(filepath.Join(dir, "history.jsonl")),
+		pollInterval: 10 * time.Millisecond,
+		strategy:     strategy,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	const games = 3
+	var results, replays []string
+	for i := 0; i < games; i++ {
+		playFullGame(ctx, t, a)
+		results = append(results, a.lastGameStatus)
+		replays = append(replays, a.recorder.Path())
+		a.endGame()
+	}
+
+	entries, err := a.history.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != games {
+		t.Fatalf("history has %d games, want %d", len(entries), games)
+	}
+	for i, e := range entries {
+		if e.Result != results[i] {
+			t.Errorf("history entry %d is a %s, want a %s", i, e.Result, results[i])
+		}
+	}
+
+	replayDir, err := replay.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(replayDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != games {
+		t.Errorf("%d replays recorded, want %d", len(files), games)
+	}
+	for i, path := range replays {
+		r, err := replay.Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Result != results[i] {
+			t.Errorf("replay of game %d ended with %q, want %q", i, r.Result, results[i])
+		}
+	}
+}
+
+// playFullGame plays a single game to its end and checks that it was
+// recorded as played.
+func playFullGame(ctx context.Context, t *testing.T, a *App) {
+	t.Helper()
+	if err := a.startGame(ctx, "", a.player, "", true, func() {}); err != nil {
+		t.Fatalf("startGame: %v", err)
+	}
+	defer a.stopEvents()
+	gameTxt := a.drawGame()
+
+	// Keep pressing "a", like a player firing every suggested shot.
+	keys := a.keys.ch
+	pressing, stopPressing := context.WithCancel(ctx)
+	pressed := make(chan struct{})
+	go func() {
+		defer close(pressed)
+		for {
+			select {
+			case keys <- tl.Event{Type: tl.EventKey, Ch: 'a'}:
+			case <-pressing.Done():
+				return
+			}
+		}
+	}()
+
+	if !a.play(ctx) {
+		t.Fatalf("play returned before the game ended: %v", ctx.Err())
+	}
+	a.displaySummary(ctx, gameTxt)
+	stopPressing()
+	<-pressed
+
+	if a.lastGameStatus != "win" && a.lastGameStatus != "lose" {
+		t.Fatalf("game ended with status %q, want win or lose", a.lastGameStatus)
+	}
+	if a.lastGameStatus == "win" && !a.game.FleetSunk() {
+		t.Errorf("won with %v of the opponent's ships left", a.game.Remaining)
+	}
+	if a.endedAt.IsZero() {
+		t.Error("end of the game was not recorded")
+	}
+
+	// A late GameEnded, e.g. from a poll racing the one that ended the game,
+	// must not be recorded again.
+	result := a.lastGameStatus
+	a.handleEvent(http.GameEnded{Result: "lose"})
+
+	entries, err := a.history.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("history is empty")
+	}
+	last := entries[len(entries)-1]
+	if last.Result != result || last.ShotsFired != a.game.ShotsFired {
+		t.Errorf("history entry %+v does not match the game: %s after %d shots", last, result, a.game.ShotsFired)
+	}
+
+	r, err := replay.Load(a.recorder.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Result != result {
+		t.Errorf("replay result is %q, want %q", r.Result, result)
+	}
+	shots := 0
+	for _, e := range r.Events {
+		if e.Type == replay.EventShot {
+			shots++
+		}
+	}
+	if shots != a.game.ShotsFired {
+		t.Errorf("replay has %d shots, want %d", shots, a.game.ShotsFired)
+	}
+
+	// Playing the replay back to its end shows the boards as they were.
+	replayed := replayState(r, a.rules, a.playerShips, len(r.Events))
+	if boardStates(replayed.Player) != boardStates(a.game.Player) {
+		t.Error("replayed player board differs from the game")
+	}
+	if boardStates(replayed.Opponent) != boardStates(a.game.Opponent) {
+		t.Error("replayed opponent board differs from the game")
+	}
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"battleship-client/game"
+	"fmt"
+
+	tl "github.com/grupawp/termloop"
+	gui "github.com/grupawp/warships-gui/v2"
+)
+
+// cursor is a field picked on a board with the keyboard, for terminals
+// without mouse support. It is moved with the arrow keys or hjkl, or by
+// typing a coordinate such as E5. Column letters must be typed in upper case,
+// lower case letters are left for commands.
+type cursor struct {
+	rules game.Rules
+	at    game.Coord
+	typed string
+}
+
+func newCursor(rules game.Rules) *cursor {
+	return &cursor{rules: rules}
+}
+
+// handle applies a key press to the cursor. used reports whether the key was
+// meant for the cursor, selected whether it picks the field under it.
+func (c *cursor) handle(e tl.Event) (used bool, selected bool) {
+	switch {
+	case e.Key == tl.KeyEnter || e.Key == tl.KeySpace || e.Ch == ' ':
+		c.typed = ""
+		return true, true
+	case e.Key == tl.KeyArrowUp || e.Ch == 'k':
+		c.move(game.Coord{X: 0, Y: -1})
+	case e.Key == tl.KeyArrowDown || e.Ch == 'j':
+		c.move(game.Coord{X: 0, Y: 1})
+	case e.Key == tl.KeyArrowLeft || e.Ch == 'h':
+		c.move(game.Coord{X: -1, Y: 0})
+	case e.Key == tl.KeyArrowRight || e.Ch == 'l':
+		c.move(game.Coord{X: 1, Y: 0})
+	case e.Key == tl.KeyBackspace || e.Key == tl.KeyBackspace2:
+		if c.typed == "" {
+			return false, false
+		}
+		c.typed = c.typed[:len(c.typed)-1]
+	case e.Ch >= 'A' && e.Ch < 'A'+rune(c.rules.Width):
+		c.typed = string(e.Ch)
+	case e.Ch >= '0' && e.Ch <= '9' && c.typed != "":
+		typed := c.typed + string(e.Ch)
+		at, err := c.rules.ParseCoord(typed)
+		if err != nil {
+			return true, false
+		}
+		c.at, c.typed = at, typed
+	default:
+		return false, false
+	}
+	return true, false
+}
+
+func (c *cursor) move(offset game.Coord) {
+	c.typed = ""
+	if at := c.at.Add(offset); at.In(c.rules.Width, c.rules.Height) {
+		c.at = at
+	}
+}
+
+// label describes the field under the cursor, or the coordinate being typed.
+func (c *cursor) label() string {
+	if len(c.typed) == 1 {
+		return fmt.Sprintf("Cursor: %s_", c.typed)
+	}
+	return fmt.Sprintf("Cursor: %s", c.at)
+}
+
+// cells marks the field under the cursor for a boardOverlay.
+func (c *cursor) cells() map[game.Coord]overlayCell {
+	return map[game.Coord]overlayCell{
+		c.at: {char: '+', fg: gui.White, bg: gui.Black},
+	}
+}
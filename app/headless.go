@@ -0,0 +1,231 @@
+package app
+
+import (
+	"battleship-client/config"
+	"battleship-client/game"
+	"battleship-client/history"
+	"battleship-client/http"
+	"battleship-client/layout"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxShotFailures is how many shots in a row may fail before a headless
+// game is given up.
+const maxShotFailures = 5
+
+// RunHeadless plays cfg.Games games without the GUI, firing the shots picked
+// by cfg.Strategy and reporting progress to w as text or JSON lines.
+func RunHeadless(ctx context.Context, client *http.Client, cfg *config.Config, w io.Writer, logger *slog.Logger) error {
+	strategy, err := NewStrategy(cfg.Strategy)
+	if err != nil {
+		return err
+	}
+
+	a := App{
+		client:            client,
+		logger:            logger,
+		player:            cfg.Nick,
+		playerDescription: cfg.Description,
+		pollInterval:      cfg.PollInterval.Duration(),
+	}
+	a.rules, err = game.ParseRules(cfg.Rules)
+	if err != nil {
+		return err
+	}
+	a.customShips, err = configFleet(a.rules, cfg.Fleet)
+	if err != nil {
+		return fmt.Errorf("invalid fleet: %w", err)
+	}
+	a.history, err = history.DefaultStore()
+	if err != nil {
+		return err
+	}
+	if cfg.Layout != "" {
+		layouts, err := layout.DefaultStore()
+		if err != nil {
+			return err
+		}
+		a.customShips, err = loadLayout(layouts, a.rules, cfg.Layout)
+		if err != nil {
+			return err
+		}
+	}
+	r := &reporter{w: w, json: cfg.Output == config.OutputJSON}
+
+	wins := 0
+	played := 0
+	failures := 0
+	for played < cfg.Games && ctx.Err() == nil {
+		err := a.playHeadless(ctx, strategy, r, cfg.GameMode != config.ModeWait)
+		if err != nil {
+			r.report("error", map[string]any{"error": err.Error()})
+			if a.lastGameStatus == "" {
+				a.abandon()
+			}
+			failures++
+			if failures >= 3 {
+				return err
+			}
+			continue
+		}
+		failures = 0
+		played++
+		if a.lastGameStatus == "win" {
+			wins++
+		}
+	}
+
+	r.report("summary", map[string]any{
+		"games":  played,
+		"wins":   wins,
+		"losses": played - wins,
+	})
+
+	return ctx.Err()
+}
+
+func (a *App) playHeadless(ctx context.Context, strategy Strategy, r *reporter, wpbot bool) error {
+	err := a.startGame(ctx, a.playerDescription, a.player, "", wpbot, func() {
+		r.report("waiting", nil)
+	})
+	if err != nil {
+		return err
+	}
+	r.report("game_started", map[string]any{
+		"nick":     a.player,
+		"opponent": a.opponent,
+	})
+	defer a.stopEvents()
+
+	// A failed shot is retried after pollInterval.
+	failures := 0
+	var retry <-chan time.Time
+	for a.lastGameStatus == "" {
+		if a.shouldFire && !a.game.FleetSunk() && retry == nil {
+			fired, err := a.fireHeadless(ctx, strategy, r)
+			if err != nil {
+				return err
+			}
+			if fired {
+				failures = 0
+				continue
+			}
+			failures++
+			if failures >= maxShotFailures {
+				return fmt.Errorf("giving up after %d failed shots in a row", failures)
+			}
+			retry = time.After(a.pollInterval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-retry:
+			retry = nil
+		case e, ok := <-a.events.Events():
+			if !ok {
+				return fmt.Errorf("game events stopped before the game ended")
+			}
+			a.handleEvent(e)
+			switch e := e.(type) {
+			case http.OpponentShot:
+				r.report("opponent_shot", map[string]any{"coord": e.Coord})
+			case http.StreamError:
+				return e.Err
+			}
+		}
+	}
+
+	r.report("game_ended", map[string]any{
+		"opponent":    a.opponent,
+		"result":      a.lastGameStatus,
+		"shots_fired": a.game.ShotsFired,
+		"shots_hit":   a.game.ShotsHit,
+		"turns":       a.game.Turns,
+		"duration":    a.endedAt.Sub(a.startedAt).Round(time.Second).String(),
+		"replay":      a.recorder.Path(),
+	})
+
+	return nil
+}
+
+// fireHeadless fires a single shot picked by strategy. It returns false if
+// the shot failed but may succeed when retried, and an error if the game
+// can't be continued.
+func (a *App) fireHeadless(ctx context.Context, strategy Strategy, r *reporter) (bool, error) {
+	c, ok := strategy.NextShot(a.game.Opponent, a.game.Remaining)
+	if !ok {
+		return false, fmt.Errorf("strategy has no shots left")
+	}
+	fireResponse, err := a.client.Fire(ctx, c.String())
+	if errors.Is(err, http.ErrBadRequest) {
+		status, statusErr := a.client.Status(ctx)
+		if statusErr != nil || !tooLate(status) {
+			a.logger.Warn("shot refused", "coord", c, "error", err)
+			return false, nil
+		}
+		// Our turn or the game ended before the shot arrived, the stream
+		// reports what happened next.
+		a.shouldFire = false
+		a.events.Poll()
+		return true, nil
+	}
+	if errors.Is(err, http.ErrNotFound) || errors.Is(err, http.ErrUnauthorized) || ctx.Err() != nil {
+		return false, err
+	}
+	if err != nil {
+		a.logger.Warn("shot failed", "coord", c, "error", err)
+		return false, nil
+	}
+	a.recordShot(c, fireResponse.Result)
+	r.report("shot", map[string]any{
+		"coord":  c.String(),
+		"result": fireResponse.Result,
+	})
+
+	if fireResponse.Result == "miss" {
+		a.shouldFire = false
+		a.events.Poll()
+	} else {
+		a.events.Poll()
+	}
+	return true, nil
+}
+
+type reporter struct {
+	w    io.Writer
+	json bool
+}
+
+func (r *reporter) report(event string, fields map[string]any) {
+	now := time.Now().Format(time.RFC3339)
+	if r.json {
+		line := map[string]any{"time": now, "event": event}
+		for k, v := range fields {
+			line[k] = v
+		}
+		data, _ := json.Marshal(line)
+		fmt.Fprintln(r.w, string(data))
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(now + " " + event)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, fields[k])
+	}
+	fmt.Fprintln(r.w, b.String())
+}
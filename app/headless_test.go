@@ -0,0 +1,84 @@
+package app
+
+import (
+	"battleship-client/config"
+	"battleship-client/fakeserver"
+	"battleship-client/http"
+	"battleship-client/logging"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunHeadless plays games in a row against wpbot. Each game, won or
+// lost, must end through the game events without a single failed shot.
+func TestRunHeadless(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	ts := fakeserver.NewTestServer(fakeserver.Options{Seed: 2})
+	defer ts.Close()
+
+	cfg := config.Default()
+	cfg.Nick = "tester"
+	cfg.Strategy = "density"
+	cfg.Games = 10
+	cfg.Output = config.OutputJSON
+	cfg.PollInterval = config.Duration(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var out bytes.Buffer
+	client := http.NewClient(ts.URL+fakeserver.APIPrefix, 5*time.Second)
+	if err := RunHeadless(ctx, client, cfg, &out, logging.Discard()); err != nil {
+		t.Fatalf("RunHeadless: %v\n%s", err, out.String())
+	}
+
+	results := map[string]int{}
+	var summary map[string]any
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid output line %q: %v", scanner.Text(), err)
+		}
+		switch line["event"] {
+		case "error":
+			t.Errorf("game failed: %v", line["error"])
+		case "game_ended":
+			results[line["result"].(string)]++
+		case "summary":
+			summary = line
+		}
+	}
+
+	if results["win"]+results["lose"] != cfg.Games {
+		t.Errorf("games ended with %v, want %d wins and losses", results, cfg.Games)
+	}
+	if summary == nil {
+		t.Fatal("no summary reported")
+	}
+	if summary["games"] != float64(cfg.Games) || summary["wins"] != float64(results["win"]) {
+		t.Errorf("summary %v does not match the games: %v", summary, results)
+	}
+}
+
+func TestRunHeadlessInvalidFleet(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg := config.Default()
+	// Every field parses, but the ships are missing.
+	cfg.Fleet = []string{"A1", "A2", "A3", "A4"}
+	var out bytes.Buffer
+	client := http.NewClient("http://127.0.0.1:0", time.Second)
+	err := RunHeadless(context.Background(), client, cfg, &out, logging.Discard())
+	if err == nil || !strings.Contains(err.Error(), "invalid fleet") {
+		t.Errorf("RunHeadless returned %v, want an invalid fleet error", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("RunHeadless started playing with an invalid fleet:\n%s", out.String())
+	}
+}
@@ -1,5 +1,7 @@
 package app
 
+import gui "github.com/grupawp/warships-gui/v2"
+
 func Filter[T any](data []T, f func(T) bool) []T {
 	r := make([]T, 0, len(data))
 
@@ -22,11 +24,9 @@ func Map[T, U any](data []T, f func(T) U) []U {
 	return r
 }
 
-func makeRequest(requestFunc func() error) {
-	for i := 0; i < 3; i++ {
-		err := requestFunc()
-		if err == nil {
-			return
-		}
+func blend(from gui.Color, to gui.Color, t float64) gui.Color {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
 	}
+	return gui.NewColor(mix(from.Red, to.Red), mix(from.Green, to.Green), mix(from.Blue, to.Blue))
 }
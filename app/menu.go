@@ -0,0 +1,539 @@
+package app
+
+import (
+	"battleship-client/history"
+	"battleship-client/http"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tl "github.com/grupawp/termloop"
+	gui "github.com/grupawp/warships-gui/v2"
+)
+
+const (
+	// pageLines and pageItems are how many lines and items of a page fit on
+	// the screen at once, the rest is scrolled.
+	pageLines = 24
+	pageItems = 14
+
+	// refreshInterval is how often pages with live content are reloaded.
+	refreshInterval = 2 * time.Second
+)
+
+// page is a full-screen menu page: a title, lines of text such as a table,
+// and items to pick from with the arrow keys or j/k and Enter.
+type page struct {
+	title  string
+	notice string
+	lines  []string
+	items  []string
+	// back is the label of an extra last item that leaves the page, like q
+	// and Backspace do. Pages without it can only be left by picking an item.
+	back string
+	// refresh, if set, reloads the lines and items every refreshInterval.
+	refresh func(ctx context.Context) pageContent
+}
+
+type pageContent struct {
+	lines []string
+	items []string
+}
+
+// show displays the page until an item is picked and returns its index in
+// items. It returns false if the page was left instead, or ctx is done.
+func (p *page) show(ctx context.Context) (int, bool) {
+	ui := gui.NewGUI(true)
+	updates := newUIQueue()
+	ui.Draw(updates)
+	keys := newKeyListener()
+	ui.Draw(keys)
+
+	ui.Draw(gui.NewText(1, 1, p.title, &gui.TextConfig{FgColor: gui.White, BgColor: gui.Black}))
+	noticeTxt := gui.NewText(1, 3, "", nil)
+	ui.Draw(noticeTxt)
+	lineTxt := make([]*gui.Text, pageLines)
+	for i := range lineTxt {
+		lineTxt[i] = gui.NewText(1, 5+i, "", nil)
+		ui.Draw(lineTxt[i])
+	}
+	// The item and help texts move with the number of lines, so they are
+	// drawn anew on every change. Only the GUI goroutine touches them.
+	var itemTxt []*gui.Text
+	helpTxt := gui.NewText(1, 0, "", nil)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	refreshed := make(chan pageContent)
+	if p.refresh != nil {
+		go func() {
+			for {
+				content := p.refresh(ctx)
+				select {
+				case refreshed <- content:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case <-time.After(refreshInterval):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	picked := -1
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer cancel()
+
+		selected, top, scroll := 0, 0, 0
+		for {
+			items := p.items
+			if p.back != "" {
+				items = append(append([]string(nil), p.items...), p.back)
+			}
+			if selected >= len(items) {
+				selected = len(items) - 1
+			}
+			if selected < 0 {
+				selected = 0
+			}
+			if selected < top {
+				top = selected
+			}
+			if selected >= top+pageItems {
+				top = selected - pageItems + 1
+			}
+			if scroll > len(p.lines)-pageLines {
+				scroll = len(p.lines) - pageLines
+			}
+			if scroll < 0 {
+				scroll = 0
+			}
+
+			lines := make([]string, pageLines)
+			copy(lines, p.lines[scroll:])
+			shown := len(p.lines)
+			if shown > pageLines {
+				shown = pageLines
+			}
+			itemsY := 5 + shown + 1
+			if shown == 0 {
+				itemsY = 5
+			}
+			visible := items[top:]
+			if len(visible) > pageItems {
+				visible = visible[:pageItems]
+			}
+			help := "Up/Down or j/k - Move, Enter - Select"
+			if p.back != "" {
+				help += ", q - " + p.back
+			}
+			if len(p.lines) > pageLines {
+				help += ", PgUp/PgDn - Scroll"
+			}
+			notice := p.notice
+			sel := selected - top
+			updates.Do(func() {
+				noticeTxt.SetText(notice)
+				for i, txt := range lineTxt {
+					txt.SetText(lines[i])
+				}
+				for _, txt := range itemTxt {
+					ui.Remove(txt)
+				}
+				itemTxt = nil
+				for i, item := range visible {
+					var cfg *gui.TextConfig
+					if i == sel {
+						cfg = &gui.TextConfig{FgColor: gui.Black, BgColor: gui.Green}
+					}
+					txt := gui.NewText(3, itemsY+i, item, cfg)
+					itemTxt = append(itemTxt, txt)
+					ui.Draw(txt)
+				}
+				ui.Remove(helpTxt)
+				helpTxt = gui.NewText(1, itemsY+len(visible)+1, help, &gui.TextConfig{FgColor: gui.White, BgColor: gui.Black})
+				ui.Draw(helpTxt)
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case content := <-refreshed:
+				p.lines, p.items = content.lines, content.items
+			case e := <-keys.ch:
+				switch {
+				case e.Key == tl.KeyArrowUp || e.Ch == 'k':
+					selected--
+				case e.Key == tl.KeyArrowDown || e.Ch == 'j':
+					selected++
+				case e.Key == tl.KeyPgup:
+					scroll -= pageLines
+				case e.Key == tl.KeyPgdn:
+					scroll += pageLines
+				case e.Key == tl.KeyEnter || e.Key == tl.KeySpace || e.Ch == ' ':
+					if len(items) == 0 {
+						continue
+					}
+					if selected < len(p.items) {
+						picked = selected
+					}
+					return
+				case e.Ch == 'q' || e.Key == tl.KeyBackspace || e.Key == tl.KeyBackspace2:
+					if p.back != "" {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	startGUI(ctx, ui)
+	cancel()
+	<-done
+	return picked, picked >= 0
+}
+
+// prompt asks for a line of text on a full-screen page. It returns false if
+// the prompt was cancelled with Ctrl+C or ctx is done.
+func prompt(ctx context.Context, title, label string) (string, bool) {
+	ui := gui.NewGUI(true)
+	updates := newUIQueue()
+	ui.Draw(updates)
+	keys := newKeyListener()
+	ui.Draw(keys)
+
+	ui.Draw(gui.NewText(1, 1, title, &gui.TextConfig{FgColor: gui.White, BgColor: gui.Black}))
+	inputTxt := gui.NewText(1, 5, label+"_", nil)
+	ui.Draw(inputTxt)
+	ui.Draw(gui.NewText(1, 7, "Enter - Confirm, Ctrl+C - Cancel", &gui.TextConfig{FgColor: gui.White, BgColor: gui.Black}))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var input []rune
+	confirmed := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer cancel()
+		for {
+			e, ok := keys.Listen(ctx)
+			if !ok {
+				return
+			}
+			switch {
+			case e.Key == tl.KeyEnter:
+				confirmed = true
+				return
+			case e.Key == tl.KeyBackspace || e.Key == tl.KeyBackspace2:
+				if len(input) > 0 {
+					input = input[:len(input)-1]
+				}
+			case e.Key == tl.KeySpace:
+				input = append(input, ' ')
+			case e.Ch != 0:
+				input = append(input, e.Ch)
+			default:
+				continue
+			}
+			text := label + string(input) + "_"
+			updates.Do(func() {
+				inputTxt.SetText(text)
+			})
+		}
+	}()
+
+	startGUI(ctx, ui)
+	cancel()
+	<-done
+	return string(input), confirmed
+}
+
+// showWhile shows p while start runs. Leaving the page first cancels the
+// context start was given. Otherwise that context outlives the page, so start
+// can begin work like following a game, and the returned stop ends it. The
+// caller owns that work and must call stop once it is done with it.
+func showWhile(ctx context.Context, p *page, start func(ctx context.Context) error) (stop context.CancelFunc, err error) {
+	startCtx, cancel := context.WithCancel(ctx)
+	shown, hide := context.WithCancel(ctx)
+	defer hide()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer hide()
+		err = start(startCtx)
+	}()
+
+	p.show(shown)
+	select {
+	case <-done:
+	default:
+		cancel()
+		<-done
+	}
+	return cancel, err
+}
+
+// notify shows a message on the next menu page.
+func (a *App) notify(format string, args ...any) {
+	a.notice = fmt.Sprintf(format, args...)
+}
+
+// displayMenu shows the main menu until a game is picked. quit is true if
+// the player chose to quit or ctx is done.
+func (a *App) displayMenu(ctx context.Context) (targetNick string, wpbot bool, quit bool) {
+	options := []string{
+		"Play against wpbot",
+		"Play against one of currently waiting players",
+		"Join waiting list",
+		"Display top 10 players stats",
+		"Display your stats",
+		"Setup your board",
+		"Save your board layout",
+		"Load a saved board layout",
+		"Delete a saved board layout",
+		"Display your match history",
+		"Quit",
+	}
+
+	for {
+		title := "Battleship"
+		if a.player != "" {
+			title += " - " + a.player
+		}
+		board := "Your board: placed by the server"
+		if len(a.customShips) > 0 {
+			board = "Your board: custom layout"
+		}
+		p := &page{title: title, notice: a.notice, lines: []string{board}, items: options}
+		a.notice = ""
+		choice, ok := p.show(ctx)
+		if !ok {
+			return "", false, true
+		}
+		switch choice + 1 {
+		case 1:
+			return "", true, false
+		case 2:
+			if nick, ok := a.chooseOpponent(ctx); ok {
+				return nick, false, false
+			}
+		case 3:
+			return "", false, false
+		case 4:
+			a.displayStats(ctx)
+		case 5:
+			a.displayPlayerStats(ctx)
+		case 6:
+			a.setupBoard(ctx)
+		case 7:
+			a.saveLayout(ctx)
+		case 8:
+			a.loadLayout(ctx)
+		case 9:
+			a.deleteLayout(ctx)
+		case 10:
+			a.displayHistory(ctx)
+		case 11:
+			return "", false, true
+		}
+	}
+}
+
+// chooseOpponent shows the players waiting for a game, refreshed while the
+// page is open, and returns the one picked.
+func (a *App) chooseOpponent(ctx context.Context) (string, bool) {
+	p := &page{
+		title: "Waiting players",
+		lines: []string{"Loading waiting players..."},
+		back:  "Back",
+		refresh: func(ctx context.Context) pageContent {
+			playersList, err := a.client.List(ctx)
+			if err != nil {
+				return pageContent{lines: []string{fmt.Sprintf("Could not fetch waiting players: %s", err)}}
+			}
+			filteredList := Filter(*playersList, func(element http.ListResponse) bool {
+				return element.GameStatus == "waiting"
+			})
+			mappedList := Map(filteredList, func(element http.ListResponse) string {
+				return element.Nick
+			})
+			if len(mappedList) == 0 {
+				return pageContent{lines: []string{"No players are waiting right now, the list refreshes every few seconds"}}
+			}
+			return pageContent{lines: []string{"Pick a player to challenge:"}, items: mappedList}
+		},
+	}
+	choice, ok := p.show(ctx)
+	if !ok {
+		return "", false
+	}
+	return p.items[choice], true
+}
+
+func (a *App) displayStats(ctx context.Context) {
+	stats, err := a.client.Stats(ctx)
+	if err != nil {
+		a.notify("Could not fetch stats: %s", err)
+		return
+	}
+
+	lines := []string{fmt.Sprintf("| %s | %-20s | %s | %s | %s |", "RANK", "NICK", "GAMES", "WINS", "POINTS")}
+	for _, s := range stats.Stats {
+		lines = append(lines, statsLine(s))
+	}
+	p := &page{title: "Top 10 players", lines: lines, back: "Back"}
+	p.show(ctx)
+}
+
+func (a *App) displayPlayerStats(ctx context.Context) {
+	stats, err := a.client.PlayerStats(ctx, a.player)
+	if errors.Is(err, http.ErrNotFound) {
+		a.notify("You have no stats yet, play a game first!")
+		return
+	}
+	if err != nil {
+		a.notify("Could not fetch your stats: %s", err)
+		return
+	}
+
+	lines := []string{
+		fmt.Sprintf("| %s | %-20s | %s | %s | %s |", "RANK", "NICK", "GAMES", "WINS", "POINTS"),
+		statsLine(stats.Stats),
+	}
+	p := &page{title: "Your stats", lines: lines, back: "Back"}
+	p.show(ctx)
+}
+
+func statsLine(s http.StatsData) string {
+	return fmt.Sprintf("| %4d | %-20s | %5d | %4d | %6d |",
+		s.Rank,
+		s.Nick,
+		s.Games,
+		s.Wins,
+		s.Points,
+	)
+}
+
+func (a *App) displayHistory(ctx context.Context) {
+	entries, err := a.history.Load()
+	if err != nil {
+		a.notify("Could not load match history: %s", err)
+		return
+	}
+	if len(entries) == 0 {
+		a.notify("No games recorded yet, play a game first!")
+		return
+	}
+	summary := history.Summarize(entries)
+
+	var lines []string
+	lines = append(lines, "Overall:")
+	lines = append(lines, historyStatsLines([]history.Stats{summary.Overall}, "")...)
+	lines = append(lines, "Per opponent:")
+	lines = append(lines, historyStatsLines(summary.ByOpponent, "OPPONENT")...)
+	lines = append(lines, "Per week:")
+	lines = append(lines, historyStatsLines(summary.ByWeek, "WEEK")...)
+
+	lines = append(lines, "Last games:")
+	lines = append(lines, fmt.Sprintf("| %-16s | %-20s | %-6s | %s | %s | %s |", "DATE", "OPPONENT", "RESULT", "SHOTS", "HITS", "TURNS"))
+	if len(entries) > 10 {
+		entries = entries[len(entries)-10:]
+	}
+	for _, e := range entries {
+		lines = append(lines, fmt.Sprintf("| %-16s | %-20s | %-6s | %5d | %4d | %5d |",
+			e.EndedAt.Format("2006-01-02 15:04"),
+			e.Opponent,
+			e.Result,
+			e.ShotsFired,
+			e.ShotsHit,
+			e.Turns,
+		))
+	}
+	p := &page{title: "Match history", lines: lines, back: "Back"}
+	p.show(ctx)
+}
+
+func historyStatsLines(stats []history.Stats, label string) []string {
+	lines := []string{fmt.Sprintf("| %-20s | %s | %s | %s | %s | %s | %s |", label, "GAMES", "WIN RATE", "ACCURACY", "SHOTS TO WIN", "TURNS", "DURATION")}
+	for _, s := range stats {
+		lines = append(lines, fmt.Sprintf("| %-20s | %5d | %7.0f%% | %7.0f%% | %12.1f | %5.1f | %8s |",
+			s.Label,
+			s.Games,
+			s.WinRate()*100,
+			s.Accuracy()*100,
+			s.AvgShotsToWin(),
+			s.AvgTurns(),
+			s.AvgDuration().Round(time.Second),
+		))
+	}
+	return append(lines, "")
+}
+
+func (a *App) saveLayout(ctx context.Context) {
+	if len(a.customShips) == 0 {
+		a.notify("You have no board set up! Setup your board first")
+		return
+	}
+	name, ok := prompt(ctx, "Save your board layout", "Layout name: ")
+	if !ok {
+		return
+	}
+	if err := a.layouts.Save(name, coordStrings(a.customShips), a.rules); err != nil {
+		a.notify("Could not save layout: %s", err)
+		return
+	}
+	a.notify("Layout saved!")
+}
+
+func (a *App) loadLayout(ctx context.Context) {
+	name, ok := a.chooseLayout(ctx, "Load a saved board layout")
+	if !ok {
+		return
+	}
+	coords, err := loadLayout(a.layouts, a.rules, name)
+	if err != nil {
+		a.notify("Could not load layout: %s", err)
+		return
+	}
+	a.customShips = coords
+	a.notify("Layout %s loaded!", name)
+}
+
+func (a *App) deleteLayout(ctx context.Context) {
+	name, ok := a.chooseLayout(ctx, "Delete a saved board layout")
+	if !ok {
+		return
+	}
+	if err := a.layouts.Delete(name); err != nil {
+		a.notify("Could not delete layout: %s", err)
+		return
+	}
+	a.notify("Layout %s deleted!", name)
+}
+
+func (a *App) chooseLayout(ctx context.Context, title string) (string, bool) {
+	names, err := a.layouts.List()
+	if err != nil {
+		a.notify("Could not list layouts: %s", err)
+		return "", false
+	}
+	if len(names) == 0 {
+		a.notify("You have no saved layouts")
+		return "", false
+	}
+	p := &page{title: title, items: names, back: "Back"}
+	choice, ok := p.show(ctx)
+	if !ok {
+		return "", false
+	}
+	return names[choice], true
+}
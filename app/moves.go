@@ -0,0 +1,72 @@
+package app
+
+import (
+	"battleship-client/game"
+	"fmt"
+
+	gui "github.com/grupawp/warships-gui/v2"
+)
+
+// movesShown is how many of the latest moves fit in the move log.
+const movesShown = 10
+
+// move is a single shot of either player.
+type move struct {
+	opponent bool
+	coord    game.Coord
+	result   game.Result
+}
+
+// opponentShot applies the opponent's shot at c and logs it.
+func (a *App) opponentShot(c game.Coord) {
+	result := a.game.OpponentShot(c)
+	a.recorder.OpponentShot(c.String(), string(result))
+	a.moves = append(a.moves, move{opponent: true, coord: c, result: result})
+}
+
+func (a *App) moveText(m move) string {
+	who := "You"
+	if m.opponent {
+		who = a.opponent
+	}
+	return fmt.Sprintf("%s fired %s - %s", who, m.coord, m.result)
+}
+
+// displayMoves shows the latest moves, the most recent one at the bottom,
+// and highlights the last shot on each board.
+func (a *App) displayMoves() {
+	first := len(a.moves) - len(a.movesTxt)
+	if first < 0 {
+		first = 0
+	}
+	for i, txt := range a.movesTxt {
+		text := ""
+		if first+i < len(a.moves) {
+			text = a.moveText(a.moves[first+i])
+		}
+		a.setText(txt, text)
+	}
+
+	ownShot := make(map[game.Coord]overlayCell)
+	opponentShot := make(map[game.Coord]overlayCell)
+	for i := len(a.moves) - 1; i >= 0 && (len(ownShot) == 0 || len(opponentShot) == 0); i-- {
+		m := a.moves[i]
+		cells := ownShot
+		if m.opponent {
+			cells = opponentShot
+		}
+		if len(cells) == 0 {
+			cells[m.coord] = lastShotCell(m.result)
+		}
+	}
+	a.lastShot.SetCells(ownShot)
+	a.lastOpponentShot.SetCells(opponentShot)
+}
+
+// lastShotCell inverts the colors of a shot field to make it stand out.
+func lastShotCell(result game.Result) overlayCell {
+	if result == game.ResultMiss {
+		return overlayCell{char: 'M', fg: gui.Grey, bg: gui.White}
+	}
+	return overlayCell{char: 'H', fg: gui.Red, bg: gui.White}
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"battleship-client/game"
+	"battleship-client/replay"
+	"context"
+	"fmt"
+
+	tl "github.com/grupawp/termloop"
+	gui "github.com/grupawp/warships-gui/v2"
+)
+
+// PlayReplay shows a recorded game in the game layout and lets the player
+// step through its moves until Ctrl+C is pressed or ctx is done.
+func PlayReplay(parent context.Context, path string) error {
+	r, err := replay.Load(path)
+	if err != nil {
+		return err
+	}
+	rules, err := game.ParseRules(r.Rules)
+	if err != nil {
+		return fmt.Errorf("invalid rules in replay %s: %w", path, err)
+	}
+	if err := checkGUIRules(rules); err != nil {
+		return err
+	}
+	ships, err := parseCoords(rules, r.PlayerFleet)
+	if err != nil {
+		return fmt.Errorf("invalid fleet in replay %s: %w", path, err)
+	}
+
+	ui := gui.NewGUI(true)
+	updates := newUIQueue()
+	ui.Draw(updates)
+	playerBoard := gui.NewBoard(1, 8, nil)
+	opponentBoard := gui.NewBoard(46, 8, nil)
+	ui.Draw(playerBoard)
+	ui.Draw(opponentBoard)
+
+	exitTxt := gui.NewText(1, 1, "Press Ctrl+C to exit", &gui.TextConfig{FgColor: gui.White, BgColor: gui.Black})
+	ui.Draw(exitTxt)
+	helpTxt := []*gui.Text{
+		gui.NewText(92, 8, "Keys:", nil),
+		gui.NewText(92, 9, "Right/N/Space - next move", nil),
+		gui.NewText(92, 10, "Left/P - previous move", nil),
+		gui.NewText(92, 11, "Home/End - first/last move", nil),
+	}
+	for _, txt := range helpTxt {
+		ui.Draw(txt)
+	}
+	vsTxt := gui.NewText(1, 5, fmt.Sprintf("%s vs %s", r.Player, r.Opponent), nil)
+	ui.Draw(vsTxt)
+	for i, line := range wrapText(r.PlayerDescription) {
+		ui.Draw(gui.NewText(1, 30+i, line, nil))
+	}
+	for i, line := range wrapText(r.OpponentDescription) {
+		ui.Draw(gui.NewText(46, 30+i, line, nil))
+	}
+	moveTxt := gui.NewText(46, 3, "", nil)
+	ui.Draw(moveTxt)
+	accuracyTxt := gui.NewText(46, 1, "", nil)
+	ui.Draw(accuracyTxt)
+	resultTxt := gui.NewText(46, 5, "", nil)
+	keys := newKeyListener()
+	ui.Draw(keys)
+
+	step := 0
+	render := func() {
+		g := replayState(r, rules, ships, step)
+		move := fmt.Sprintf("Move 0/%d", len(r.Events))
+		if step > 0 {
+			e := r.Events[step-1]
+			who := "You"
+			if e.Type == replay.EventOpponentShot {
+				who = r.Opponent
+			}
+			move = fmt.Sprintf("Move %d/%d: %s fired %s - %s", step, len(r.Events), who, e.Coord, e.Result)
+		}
+		ended := step == len(r.Events) && r.Result != ""
+
+		updates.Do(func() {
+			playerBoard.SetStates(boardStates(g.Player))
+			opponentBoard.SetStates(boardStates(g.Opponent))
+			accuracyTxt.SetText(fmt.Sprintf("Accuracy: %d/%d", g.ShotsHit, g.ShotsFired))
+			moveTxt.SetText(move)
+			ui.Remove(resultTxt)
+			if ended {
+				resultTxt = gui.NewText(46, 5, fmt.Sprintf("Result: %s", r.Result), &gui.TextConfig{FgColor: gui.Black, BgColor: gui.White})
+				ui.Draw(resultTxt)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	go func() {
+		render()
+		for {
+			e, ok := keys.Listen(ctx)
+			if !ok {
+				return
+			}
+			switch {
+			case e.Ch == 'n' || e.Ch == 'N' || e.Ch == 0 && (e.Key == tl.KeyArrowRight || e.Key == tl.KeySpace):
+				if step < len(r.Events) {
+					step++
+				}
+			case e.Ch == 'p' || e.Ch == 'P' || e.Ch == 0 && e.Key == tl.KeyArrowLeft:
+				if step > 0 {
+					step--
+				}
+			case e.Ch == 0 && e.Key == tl.KeyHome:
+				step = 0
+			case e.Ch == 0 && e.Key == tl.KeyEnd:
+				step = len(r.Events)
+			default:
+				continue
+			}
+			render()
+		}
+	}()
+
+	startGUI(ctx, ui)
+	return nil
+}
+
+// replayState rebuilds the game state after the first step events of r.
+func replayState(r *replay.Replay, rules game.Rules, ships []game.Coord, step int) *game.Game {
+	g := game.New(rules, ships)
+	for _, e := range r.Events[:step] {
+		c, err := rules.ParseCoord(e.Coord)
+		if err != nil {
+			continue
+		}
+		switch e.Type {
+		case replay.EventShot:
+			g.Shot(c, game.Result(e.Result))
+		case replay.EventOpponentShot:
+			g.OpponentShot(c)
+		}
+	}
+	return g
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"battleship-client/game"
+	"battleship-client/http"
+	"battleship-client/replay"
+	"battleship-client/session"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// errGameOver is returned by resume when the server no longer has the game
+// in progress.
+var errGameOver = errors.New("the game is not in progress")
+
+// saveSession remembers the game in progress and our shots so far, so it can
+// be resumed if the client stops before it ends.
+func (a *App) saveSession() {
+	if a.sessions == nil {
+		return
+	}
+	var shots []session.Shot
+	opponentShots := 0
+	for _, m := range a.moves {
+		if m.opponent {
+			opponentShots++
+			continue
+		}
+		shots = append(shots, session.Shot{Coord: m.coord.String(), Result: string(m.result), After: opponentShots})
+	}
+	err := a.sessions.Save(session.Session{
+		ServerURL: a.serverURL,
+		Token:     a.client.Token(),
+		Player:    a.player,
+		Opponent:  a.opponent,
+		Rules:     replayRules(a.rules),
+		Replay:    a.recorder.Path(),
+		StartedAt: a.startedAt,
+		Shots:     shots,
+	})
+	if err != nil {
+		a.logger.Warn("could not save the session", "error", err)
+	}
+}
+
+func (a *App) clearSession() {
+	if a.sessions == nil {
+		return
+	}
+	if err := a.sessions.Clear(); err != nil {
+		a.logger.Warn("could not clear the session", "error", err)
+	}
+}
+
+// resumeSession loads the game the client was playing when it last stopped,
+// if that game is still in progress. It reports whether there is a game to
+// return to, and if so returns stop to call once the game is over.
+func (a *App) resumeSession(ctx context.Context) (stop context.CancelFunc, ok bool) {
+	if a.sessions == nil {
+		return nil, false
+	}
+	s, err := a.sessions.Load()
+	if err != nil {
+		a.logger.Warn("could not load the session", "error", err)
+		return nil, false
+	}
+	if s == nil {
+		return nil, false
+	}
+	if s.ServerURL != a.serverURL || s.Rules != replayRules(a.rules) {
+		a.logger.Info("not resuming a game played on another server or with other rules",
+			"server", s.ServerURL, "rules", s.Rules)
+		return nil, false
+	}
+
+	p := &page{title: fmt.Sprintf("Resuming your game against %s...", s.Opponent), back: "Cancel"}
+	stop, err = showWhile(ctx, p, func(ctx context.Context) error {
+		return a.resume(ctx, s)
+	})
+	if err != nil {
+		stop()
+		a.logger.Info("could not resume the game", "opponent", s.Opponent, "error", err)
+		// Only forget the game once the server says it's gone, after e.g. an
+		// outage it can still be resumed on the next start.
+		switch {
+		case errors.Is(err, context.Canceled):
+			a.notify("Resuming the game was cancelled, restart the client to try again")
+		case errors.Is(err, errGameOver) || errors.Is(err, http.ErrNotFound) || errors.Is(err, http.ErrUnauthorized):
+			a.notify("Could not resume the game: %s", err)
+			a.clearSession()
+		default:
+			a.notify("Could not resume the game, restart the client to try again: %s", err)
+		}
+		return nil, false
+	}
+	a.logger.Info("resumed the game", "opponent", a.opponent, "shots_fired", a.game.ShotsFired)
+	return stop, true
+}
+
+// resume rebuilds the state of the game s from the server and the shots
+// journaled in the session, and starts following it.
+func (a *App) resume(ctx context.Context, s *session.Session) error {
+	a.reset()
+	a.client.SetToken(s.Token)
+	status, err := a.client.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch game status: %w", err)
+	}
+	switch status.GameStatus {
+	case "game_in_progress":
+	case "ended":
+		return fmt.Errorf("%w, it has already ended", errGameOver)
+	default:
+		return errGameOver
+	}
+
+	board, err := a.client.Board(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch your board: %w", err)
+	}
+	desc, err := a.client.Description(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch game description: %w", err)
+	}
+	a.player = desc.Nick
+	a.playerDescription = desc.Desc
+	a.opponent = desc.Opponent
+	a.opponentDescription = desc.OppDesc
+	a.playerShips, err = parseCoords(a.rules, board)
+	if err != nil {
+		return fmt.Errorf("invalid board: %w", err)
+	}
+	a.game = game.New(a.rules, a.playerShips)
+	a.startedAt = s.StartedAt
+
+	if s.Replay != "" {
+		a.recorder, err = replay.Open(s.Replay)
+		if err != nil {
+			a.logger.Warn("could not reopen the replay", "error", err)
+			a.recorder = nil
+		}
+	}
+	// The replay already holds the opponent shots made while the client
+	// was running, only the ones made since are recorded.
+	recorded := 0
+	for _, e := range a.recorder.Replay().Events {
+		if e.Type == replay.EventOpponentShot {
+			recorded++
+		}
+	}
+
+	// The server doesn't report our own shots, they are taken from the
+	// session journal and replayed in order with the opponent's.
+	opponentShots := 0
+	replayOpponentShots := func(n int) error {
+		for ; opponentShots < n && opponentShots < len(status.OppShots); opponentShots++ {
+			c, err := a.rules.ParseCoord(status.OppShots[opponentShots])
+			if err != nil {
+				return fmt.Errorf("invalid opponent shot: %w", err)
+			}
+			if opponentShots < recorded {
+				result := a.game.OpponentShot(c)
+				a.moves = append(a.moves, move{opponent: true, coord: c, result: result})
+			} else {
+				a.opponentShot(c)
+			}
+		}
+		return nil
+	}
+	for _, shot := range s.Shots {
+		if err := replayOpponentShots(shot.After); err != nil {
+			return err
+		}
+		c, err := a.rules.ParseCoord(shot.Coord)
+		if err != nil {
+			return fmt.Errorf("invalid shot in the session: %w", err)
+		}
+		result := game.Result(shot.Result)
+		a.game.Shot(c, result)
+		a.moves = append(a.moves, move{coord: c, result: result})
+	}
+	if err := replayOpponentShots(len(status.OppShots)); err != nil {
+		return err
+	}
+	a.shouldFire = status.ShouldFire
+	if a.shouldFire {
+		a.turn = len(status.OppShots)
+	}
+	a.timer = status.Timer
+
+	eventsCtx, stopEvents := context.WithCancel(ctx)
+	a.events = a.client.EventsAfter(eventsCtx, a.pollInterval, len(status.OppShots))
+	a.stopEvents = stopEvents
+	return nil
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"battleship-client/fakeserver"
+	"battleship-client/game"
+	"battleship-client/http"
+	"battleship-client/logging"
+	"battleship-client/session"
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestResume plays part of a game against wpbot, then resumes it from the
+// session alone. Our shots must come back even without the replay.
+func TestResume(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	ts := fakeserver.NewTestServer(fakeserver.Options{Seed: 1})
+	defer ts.Close()
+	serverURL := ts.URL + fakeserver.APIPrefix
+
+	strategy, err := NewStrategy("density")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newApp := func() *App {
+		return &App{
+			client:       http.NewClient(serverURL, 5*time.Second),
+			logger:       logging.Discard(),
+			serverURL:    serverURL,
+			rules:        game.StandardRules(),
+			sessions:     session.NewStore(filepath.Join(dir, "session.json")),
+			pollInterval: 10 * time.Millisecond,
+			strategy:     strategy,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	a := newApp()
+	if err := a.startGame(ctx, "", "tester", "", true, func() {}); err != nil {
+		t.Fatalf("startGame: %v", err)
+	}
+	a.stopEvents()
+
+	// Fire without the game loop, so both players' shots are applied in
+	// the order the server saw them. The last status only catches up with
+	// the opponent's shots.
+	opponentShots := 0
+	for {
+		status, err := a.client.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.GameStatus != "game_in_progress" {
+			t.Fatalf("game ended after %d shots", a.game.ShotsFired)
+		}
+		for _, coord := range status.OppShots[opponentShots:] {
+			c, err := a.rules.ParseCoord(coord)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a.opponentShot(c)
+		}
+		opponentShots = len(status.OppShots)
+		if a.game.ShotsFired >= 30 {
+			break
+		}
+		if !status.ShouldFire {
+			continue
+		}
+		c, _ := a.strategy.NextShot(a.game.Opponent, a.game.Remaining)
+		res, err := a.client.Fire(ctx, c.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.recordShot(c, res.Result)
+		a.saveSession()
+	}
+	if opponentShots == 0 {
+		t.Fatal("the opponent never fired")
+	}
+
+	s, err := a.sessions.Load()
+	if err != nil || s == nil {
+		t.Fatalf("Load() = %v, %v, want the session", s, err)
+	}
+	s.Replay = ""
+
+	b := newApp()
+	if err := b.resume(ctx, s); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	defer b.stopEvents()
+
+	if boardStates(b.game.Opponent) != boardStates(a.game.Opponent) {
+		t.Error("resumed opponent board differs from the game")
+	}
+	if boardStates(b.game.Player) != boardStates(a.game.Player) {
+		t.Error("resumed player board differs from the game")
+	}
+	if b.game.ShotsFired != a.game.ShotsFired || b.game.ShotsHit != a.game.ShotsHit {
+		t.Errorf("resumed accuracy %d/%d, want %d/%d", b.game.ShotsHit, b.game.ShotsFired, a.game.ShotsHit, a.game.ShotsFired)
+	}
+	if !reflect.DeepEqual(b.moves, a.moves) {
+		t.Errorf("resumed moves differ from the game:\n%v\nwant\n%v", b.moves, a.moves)
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// interrupts handles SIGINT and SIGTERM for the interactive client. A signal
+// cancels the current scope: during a game the GUI closes and the game is
+// abandoned, at the menu the client quits. A second signal that arrives
+// before the scope has been wound down force quits.
+type interrupts struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	stopping bool
+}
+
+func newInterrupts() *interrupts {
+	h := &interrupts{}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go h.watch(signals)
+	return h
+}
+
+func (h *interrupts) watch(signals <-chan os.Signal) {
+	for range signals {
+		h.mu.Lock()
+		switch {
+		case h.stopping:
+			fmt.Println("\nForce quit")
+			os.Exit(1)
+		case h.cancel != nil:
+			h.stopping = true
+			h.cancel()
+		default:
+			fmt.Println()
+			os.Exit(0)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// scope returns a context cancelled on SIGINT or SIGTERM, for a game or the
+// menu. done must be called once the scope has been wound down.
+func (h *interrupts) scope() (ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.mu.Lock()
+	h.cancel = cancel
+	h.stopping = false
+	h.mu.Unlock()
+	return ctx, func() {
+		cancel()
+		h.mu.Lock()
+		h.cancel = nil
+		h.stopping = false
+		h.mu.Unlock()
+	}
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"battleship-client/game"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Strategy picks the next field to fire at, given what is known about the
+// opponent board and how many ships of each length are still afloat. It
+// returns false if there is nothing left to fire at.
+type Strategy interface {
+	NextShot(board *game.Board, remaining game.Fleet) (game.Coord, bool)
+}
+
+func NewStrategy(name string) (Strategy, error) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	switch name {
+	case "random":
+		return &randomStrategy{rand: r}, nil
+	case "hunt":
+		return &huntTargetStrategy{rand: r}, nil
+	case "density":
+		return &densityStrategy{rand: r}, nil
+	}
+	return nil, fmt.Errorf("unknown strategy %q", name)
+}
+
+type randomStrategy struct {
+	rand *rand.Rand
+}
+
+func (s *randomStrategy) NextShot(board *game.Board, _ game.Fleet) (game.Coord, bool) {
+	return pick(s.rand, board.Find(game.Empty))
+}
+
+// huntTargetStrategy fires at a checkerboard pattern, spaced out to the
+// smallest ship still afloat, until it hits a ship, then finishes that ship
+// by firing along its line.
+type huntTargetStrategy struct {
+	rand *rand.Rand
+}
+
+func (s *huntTargetStrategy) NextShot(board *game.Board, remaining game.Fleet) (game.Coord, bool) {
+	if targets := targetCells(board); len(targets) > 0 {
+		return pick(s.rand, targets)
+	}
+
+	smallest := 1
+	if lengths := remaining.Lengths(); len(lengths) > 0 {
+		smallest = lengths[len(lengths)-1]
+	}
+	// Parity 1 would allow every cell, so hunt on at least a checkerboard.
+	parity := max(2, smallest)
+	candidates := Filter(board.Find(game.Empty), func(c game.Coord) bool {
+		return (c.X+c.Y)%parity == 0
+	})
+	if len(candidates) == 0 {
+		candidates = board.Find(game.Empty)
+	}
+	return pick(s.rand, candidates)
+}
+
+// targetCells returns the unknown cells that can extend a hit but not yet
+// sunk ship.
+func targetCells(board *game.Board) []game.Coord {
+	var targets []game.Coord
+	visited := make(map[game.Coord]bool)
+	for _, c := range board.Find(game.Hit) {
+		if visited[c] {
+			continue
+		}
+		ship := board.ShipAt(c)
+		for _, part := range ship {
+			visited[part] = true
+		}
+
+		offsets := game.SideOffsets
+		if len(ship) > 1 && ship[0].X == ship[1].X {
+			offsets = []game.Coord{{X: 0, Y: 1}, {X: 0, Y: -1}}
+		} else if len(ship) > 1 {
+			offsets = []game.Coord{{X: 1, Y: 0}, {X: -1, Y: 0}}
+		}
+		for _, part := range ship {
+			for _, offset := range offsets {
+				n := part.Add(offset)
+				if board.InBounds(n) && board.At(n) == game.Empty {
+					targets = append(targets, n)
+				}
+			}
+		}
+	}
+	return targets
+}
+
+// densityStrategy fires at the cell covered by the most placements of the
+// remaining ships that are consistent with the board.
+type densityStrategy struct {
+	rand *rand.Rand
+}
+
+func (s *densityStrategy) NextShot(board *game.Board, remaining game.Fleet) (game.Coord, bool) {
+	density := Density(board, remaining)
+	var best []game.Coord
+	bestScore := 0.0
+	for _, c := range board.Find(game.Empty) {
+		switch score := density[c.X][c.Y]; {
+		case score > bestScore:
+			best = []game.Coord{c}
+			bestScore = score
+		case score == bestScore:
+			best = append(best, c)
+		}
+	}
+	return pick(s.rand, best)
+}
+
+// Density counts, for every cell, the placements of the remaining ships that
+// cover it, indexed by column and row. Placements may not overlap misses or
+// sunk ships and may not touch any hit they do not include, following the
+// no-touching rule. When there are unsunk hits only placements extending them
+// are counted.
+func Density(board *game.Board, remaining game.Fleet) [][]float64 {
+	density := make([][]float64, board.Width())
+	for x := range density {
+		density[x] = make([]float64, board.Height())
+	}
+	hits := board.Find(game.Hit)
+	active := make(map[game.Coord]bool, len(hits))
+	for _, c := range hits {
+		active[c] = true
+	}
+
+	for length, count := range remaining {
+		if count <= 0 {
+			continue
+		}
+		for _, ship := range board.Placements(length) {
+			if !board.Fits(ship) {
+				continue
+			}
+			weight := float64(count)
+			if len(active) > 0 {
+				covered := 0
+				for _, c := range ship {
+					if active[c] {
+						covered++
+					}
+				}
+				if covered == 0 {
+					continue
+				}
+				weight *= float64(covered)
+			}
+			for _, c := range ship {
+				density[c.X][c.Y] += weight
+			}
+		}
+	}
+
+	return density
+}
+
+func pick(r *rand.Rand, coords []game.Coord) (game.Coord, bool) {
+	if len(coords) == 0 {
+		return game.Coord{}, false
+	}
+	return coords[r.Intn(len(coords))], true
+}
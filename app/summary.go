@@ -0,0 +1,105 @@
+package app
+
+import (
+	"battleship-client/game"
+	"context"
+	"fmt"
+	"time"
+
+	gui "github.com/grupawp/warships-gui/v2"
+)
+
+// displaySummary replaces the game texts with the statistics of the game that
+// just ended and waits for a key press. On the opponent's board it outlines
+// the sunk ships and shows where the ships that are still afloat may be.
+func (a *App) displaySummary(ctx context.Context, gameTxt []*gui.Text) {
+	var resultTxt *gui.Text
+	switch a.lastGameStatus {
+	case "win":
+		resultTxt = gui.NewText(1, 1, "You won!", &gui.TextConfig{FgColor: gui.Black, BgColor: gui.Green})
+	case "":
+		resultTxt = gui.NewText(1, 1, "Game is no longer available!", &gui.TextConfig{FgColor: gui.Black, BgColor: gui.Red})
+	default:
+		resultTxt = gui.NewText(1, 1, "You lost!", &gui.TextConfig{FgColor: gui.Black, BgColor: gui.Red})
+	}
+
+	endedAt := a.endedAt
+	if endedAt.IsZero() {
+		endedAt = time.Now()
+	}
+	accuracy := 0.0
+	if a.game.ShotsFired > 0 {
+		accuracy = float64(a.game.ShotsHit) / float64(a.game.ShotsFired) * 100
+	}
+	sunk := 0
+	for length, count := range a.rules.Fleet {
+		sunk += count - a.game.Remaining[length]
+	}
+	lines := []string{
+		"Summary:",
+		fmt.Sprintf("Accuracy: %d/%d (%.0f%%)", a.game.ShotsHit, a.game.ShotsFired, accuracy),
+		fmt.Sprintf("Turns: %d", a.game.Turns),
+		fmt.Sprintf("Duration: %s", endedAt.Sub(a.startedAt).Round(time.Second)),
+		fmt.Sprintf("Ships sunk: %d/%d", sunk, len(a.rules.Ships())),
+		fmt.Sprintf("Opponent shots: %d", len(a.game.OpponentShots)),
+		"",
+		"Legend:",
+		"# - Sunk ship",
+		"? - Possible ship position",
+		"! - Must be a ship",
+		". - Cannot contain a ship",
+	}
+	cells := summaryCells(a.game)
+
+	a.updates.Do(func() {
+		for _, txt := range gameTxt {
+			a.ui.Remove(txt)
+		}
+		for _, txt := range a.shipsInfoTxt {
+			a.ui.Remove(txt)
+		}
+		a.ui.Remove(a.yourTurnTxt)
+		a.ui.Remove(a.opponentTurnTxt)
+		a.ui.Remove(a.timerTxt)
+		a.ui.Remove(a.accuracyTxt)
+		a.ui.Remove(a.autoPlayTxt)
+		a.ui.Remove(a.heatmap)
+		a.ui.Remove(a.cursorOverlay)
+		a.ui.Remove(a.cursorTxt)
+
+		a.ui.Draw(resultTxt)
+		a.ui.Draw(gui.NewText(1, 3, "Press any key to return to the menu", nil))
+		a.ui.Draw(gui.NewText(46, 5, "Opponent board", nil))
+		for i, line := range lines {
+			a.ui.Draw(gui.NewText(92, 8+i, line, nil))
+		}
+	})
+	a.setStates(a.playerBoard, boardStates(a.game.Player))
+	a.setStates(a.opponentBoard, boardStates(a.game.Opponent))
+	a.inference.SetCells(cells)
+
+	a.keys.Listen(ctx)
+}
+
+// summaryCells marks the sunk ships, the fields where the remaining ships may
+// be and the fields known to be empty on the opponent's board.
+func summaryCells(g *game.Game) map[game.Coord]overlayCell {
+	cells := make(map[game.Coord]overlayCell)
+	for _, c := range g.RuledOut {
+		cells[c] = overlayCell{char: '.', fg: gui.White, bg: gui.Grey}
+	}
+	for _, c := range g.Opponent.Find(game.Sunk) {
+		cells[c] = overlayCell{char: '#', fg: gui.White, bg: gui.Red}
+	}
+	if g.FleetSunk() {
+		return cells
+	}
+	inference := game.Infer(g.Opponent, g.Remaining)
+	for _, c := range inference.Possible {
+		cells[c] = overlayCell{char: '?', fg: gui.Black, bg: gui.Blue}
+	}
+	for _, c := range inference.Certain {
+		cells[c] = overlayCell{char: '!', fg: gui.Black, bg: gui.Green}
+	}
+	return cells
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"battleship-client/game"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	tl "github.com/grupawp/termloop"
+	gui "github.com/grupawp/warships-gui/v2"
+	"github.com/nsf/termbox-go"
+)
+
+// keyListener is an invisible drawable forwarding key presses from the GUI.
+type keyListener struct {
+	id uuid.UUID
+	ch chan tl.Event
+}
+
+func newKeyListener() *keyListener {
+	return &keyListener{
+		id: uuid.New(),
+		ch: make(chan tl.Event, 16),
+	}
+}
+
+func (k *keyListener) ID() uuid.UUID {
+	return k.id
+}
+
+func (k *keyListener) Drawables() []tl.Drawable {
+	return []tl.Drawable{k}
+}
+
+func (k *keyListener) Draw(*tl.Screen) {}
+
+func (k *keyListener) Tick(e tl.Event) {
+	if e.Type != tl.EventKey {
+		return
+	}
+	select {
+	case k.ch <- e:
+	default:
+		// drop
+	}
+}
+
+// Listen blocks until a key is pressed or ctx is done.
+func (k *keyListener) Listen(ctx context.Context) (tl.Event, bool) {
+	select {
+	case e := <-k.ch:
+		return e, true
+	case <-ctx.Done():
+		return tl.Event{}, false
+	}
+}
+
+// uiQueue is an invisible drawable running queued changes to other widgets on
+// the GUI goroutine before they are drawn. The GUI widgets are not safe for
+// concurrent use, so code running in other goroutines changes them through
+// Do. It must be the first drawable added to the GUI, so adding and removing
+// other drawables from it doesn't disturb the frame being drawn.
+type uiQueue struct {
+	id      uuid.UUID
+	mu      sync.Mutex
+	pending []func()
+}
+
+func newUIQueue() *uiQueue {
+	return &uiQueue{id: uuid.New()}
+}
+
+func (q *uiQueue) ID() uuid.UUID {
+	return q.id
+}
+
+func (q *uiQueue) Drawables() []tl.Drawable {
+	return []tl.Drawable{q}
+}
+
+func (q *uiQueue) Tick(tl.Event) {}
+
+func (q *uiQueue) Draw(*tl.Screen) {
+	q.mu.Lock()
+	pending := q.pending
+	q.pending = nil
+	q.mu.Unlock()
+
+	for _, f := range pending {
+		f()
+	}
+}
+
+// Do queues f to run on the GUI goroutine when the next frame is drawn.
+func (q *uiQueue) Do(f func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = append(q.pending, f)
+}
+
+type overlayCell struct {
+	char byte
+	fg   gui.Color
+	bg   gui.Color
+}
+
+// boardOverlay draws cells on top of a gui.Board placed at the same x and y.
+type boardOverlay struct {
+	id    uuid.UUID
+	x     int
+	y     int
+	mu    sync.Mutex
+	cells map[game.Coord]overlayCell
+}
+
+func newBoardOverlay(x, y int) *boardOverlay {
+	return &boardOverlay{
+		id:    uuid.New(),
+		x:     x,
+		y:     y,
+		cells: make(map[game.Coord]overlayCell),
+	}
+}
+
+func (o *boardOverlay) ID() uuid.UUID {
+	return o.id
+}
+
+func (o *boardOverlay) Drawables() []tl.Drawable {
+	return []tl.Drawable{o}
+}
+
+func (o *boardOverlay) Tick(tl.Event) {}
+
+func (o *boardOverlay) Draw(s *tl.Screen) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for p, c := range o.cells {
+		fg, bg := toAttr(c.fg), toAttr(c.bg)
+		x := o.x + (p.X+1)*4
+		y := o.y + (p.Y+1)*2
+		s.RenderCell(x, y, &tl.Cell{Fg: fg, Bg: bg, Ch: ' '})
+		s.RenderCell(x+1, y, &tl.Cell{Fg: fg, Bg: bg, Ch: rune(c.char)})
+		s.RenderCell(x+2, y, &tl.Cell{Fg: fg, Bg: bg, Ch: ' '})
+	}
+}
+
+func (o *boardOverlay) SetCells(cells map[game.Coord]overlayCell) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.cells = cells
+}
+
+func (o *boardOverlay) Clear() {
+	o.SetCells(make(map[game.Coord]overlayCell))
+}
+
+func toAttr(c gui.Color) tl.Attr {
+	return tl.RgbTo256Color(int(c.Red), int(c.Green), int(c.Blue))
+}
+
+// startGUI shows ui until ctx is done or Ctrl+C is pressed. termloop leaves
+// its input goroutine blocked in termbox.PollEvent when the GUI stops, where
+// it would race the next GUI for key presses, so it is woken up here.
+func startGUI(ctx context.Context, ui *gui.GUI) {
+	ui.Start(ctx, nil)
+
+	released := make(chan struct{})
+	go func() {
+		termbox.Interrupt()
+		close(released)
+	}()
+	select {
+	case <-released:
+	case <-time.After(100 * time.Millisecond):
+		// The goroutine is not polling, e.g. it's stuck handing over a
+		// key press nobody reads anymore.
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"battleship-client/fakeserver"
+	"battleship-client/fleet"
+	"battleship-client/game"
+	"flag"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	turnTimeout := flag.Duration("turn-timeout", 60*time.Second, "time a player has for a move")
+	waitTimeout := flag.Duration("wait-timeout", 60*time.Second, "time a waiting player stays on the list without refresh")
+	seed := flag.Int64("seed", 0, "random seed, 0 for time based")
+	rulesSpec := flag.String("rules", "", "house rules as WIDTHxHEIGHT[:SHIP_LENGTHS], e.g. 12x12:5,4,4,3,3,2,2")
+	flag.Parse()
+
+	rules, err := game.ParseRules(*rulesSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := fleet.Random(rand.New(rand.NewSource(*seed)), rules, fleet.Options{}); err != nil {
+		log.Fatal(err)
+	}
+
+	server := fakeserver.New(fakeserver.Options{
+		TurnTimeout: *turnTimeout,
+		WaitTimeout: *waitTimeout,
+		Seed:        *seed,
+		Rules:       rules,
+	})
+
+	log.Printf("fake server listening on http://%s%s, rules %s", *addr, fakeserver.APIPrefix, rules)
+	log.Fatal(http.ListenAndServe(*addr, server.Handler()))
+}
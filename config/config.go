@@ -0,0 +1,312 @@
+// Package config loads client settings from defaults, a JSON file in the
+// user config directory, BATTLESHIP_* environment variables and command-line
+// flags, each overriding the previous one.
+package config
+
+import (
+	"battleship-client/game"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ModeMenu  = "menu"
+	ModeWpbot = "wpbot"
+	ModeWait  = "wait"
+
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+type Config struct {
+	ServerURL    string   `json:"server_url"`
+	Nick         string   `json:"nick"`
+	Description  string   `json:"description"`
+	Timeout      Duration `json:"timeout"`
+	PollInterval Duration `json:"poll_interval"`
+	Fleet        []string `json:"fleet,omitempty"`
+	Layout       string   `json:"layout,omitempty"`
+	Rules        string   `json:"rules,omitempty"`
+	GameMode     string   `json:"game_mode"`
+	Headless     bool     `json:"headless"`
+	Strategy     string   `json:"strategy"`
+	Games        int      `json:"games"`
+	Output       string   `json:"output"`
+	LogFile      string   `json:"log_file,omitempty"`
+	Debug        bool     `json:"debug"`
+
+	// NickSet reports whether the nick was provided by any source, so an
+	// explicitly empty nick (random one) is not asked for again.
+	NickSet bool `json:"-"`
+}
+
+// Duration is a time.Duration stored as a string such as "30s" in the
+// config file.
+type Duration time.Duration
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %s", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func Default() *Config {
+	return &Config{
+		ServerURL:    "https://go-pjatk-server.fly.dev/api",
+		Timeout:      Duration(30 * time.Second),
+		PollInterval: Duration(time.Second),
+		GameMode:     ModeMenu,
+		Strategy:     "density",
+		Games:        1,
+		Output:       OutputText,
+	}
+}
+
+// Dir returns the directory holding the client's files.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating user config dir: %s", err)
+	}
+	return filepath.Join(dir, "battleship-client"), nil
+}
+
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// Load builds the configuration for the given command-line arguments.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	flags := flag.NewFlagSet("battleship-client", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("BATTLESHIP_CONFIG"), "path to the config file")
+	serverURL := flags.String("server", "", "game server API URL")
+	nick := flags.String("nick", "", "your nick, empty for random")
+	description := flags.String("desc", "", "your description")
+	timeout := flags.Duration("timeout", 0, "HTTP request timeout")
+	pollInterval := flags.Duration("poll", 0, "game status poll interval during the opponent's turn")
+	fleet := flags.String("fleet", "", "comma separated fleet coordinates, e.g. A1,A2,A3,A4,...")
+	layout := flags.String("layout", "", "name of a saved board layout to play with")
+	rules := flags.String("rules", "", "house rules matching the server's, as WIDTHxHEIGHT[:SHIP_LENGTHS], e.g. 12x12:5,4,4,3,3,2,2")
+	gameMode := flags.String("mode", "", "game mode to start with: menu, wpbot or wait")
+	headless := flags.Bool("headless", false, "play without the GUI using a targeting strategy")
+	strategy := flags.String("strategy", "", "targeting strategy used in headless mode: random, hunt or density")
+	games := flags.Int("games", 0, "number of games to play in headless mode")
+	output := flags.String("output", "", "headless progress format: text or json")
+	logFile := flags.String("log", "", "path to the log file, client.log in the config dir by default")
+	debug := flags.Bool("debug", false, "log every request to the server and its response")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path == "" {
+		defaultPath, err := DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+		*path = defaultPath
+	}
+	if err := cfg.loadFile(*path); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server":
+			cfg.ServerURL = *serverURL
+		case "nick":
+			cfg.Nick = *nick
+			cfg.NickSet = true
+		case "desc":
+			cfg.Description = *description
+		case "timeout":
+			cfg.Timeout = Duration(*timeout)
+		case "poll":
+			cfg.PollInterval = Duration(*pollInterval)
+		case "fleet":
+			cfg.Fleet = splitFleet(*fleet)
+		case "layout":
+			cfg.Layout = *layout
+		case "rules":
+			cfg.Rules = *rules
+		case "mode":
+			cfg.GameMode = *gameMode
+		case "headless":
+			cfg.Headless = *headless
+		case "strategy":
+			cfg.Strategy = *strategy
+		case "games":
+			cfg.Games = *games
+		case "output":
+			cfg.Output = *output
+		case "log":
+			cfg.LogFile = *logFile
+		case "debug":
+			cfg.Debug = *debug
+		}
+	})
+
+	return cfg, cfg.validate()
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading config file: %s", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("error parsing config file %s: %s", path, err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("error parsing config file %s: %s", path, err)
+	}
+	_, c.NickSet = raw["nick"]
+
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	if v, ok := os.LookupEnv("BATTLESHIP_SERVER_URL"); ok {
+		c.ServerURL = v
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_NICK"); ok {
+		c.Nick = v
+		c.NickSet = true
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_DESCRIPTION"); ok {
+		c.Description = v
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_TIMEOUT"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid BATTLESHIP_TIMEOUT: %s", err)
+		}
+		c.Timeout = Duration(d)
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_POLL_INTERVAL"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid BATTLESHIP_POLL_INTERVAL: %s", err)
+		}
+		c.PollInterval = Duration(d)
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_FLEET"); ok {
+		c.Fleet = splitFleet(v)
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_LAYOUT"); ok {
+		c.Layout = v
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_RULES"); ok {
+		c.Rules = v
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_GAME_MODE"); ok {
+		c.GameMode = v
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_HEADLESS"); ok {
+		headless, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid BATTLESHIP_HEADLESS: %s", err)
+		}
+		c.Headless = headless
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_STRATEGY"); ok {
+		c.Strategy = v
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_GAMES"); ok {
+		games, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid BATTLESHIP_GAMES: %s", err)
+		}
+		c.Games = games
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_OUTPUT"); ok {
+		c.Output = v
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_LOG_FILE"); ok {
+		c.LogFile = v
+	}
+	if v, ok := os.LookupEnv("BATTLESHIP_DEBUG"); ok {
+		debug, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid BATTLESHIP_DEBUG: %s", err)
+		}
+		c.Debug = debug
+	}
+	return nil
+}
+
+func (c *Config) validate() error {
+	if c.ServerURL == "" {
+		return fmt.Errorf("server URL must not be empty")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("poll interval must be positive")
+	}
+	switch c.GameMode {
+	case ModeMenu, ModeWpbot, ModeWait:
+	default:
+		return fmt.Errorf("unknown game mode %q, expected %s, %s or %s", c.GameMode, ModeMenu, ModeWpbot, ModeWait)
+	}
+	if _, err := game.ParseRules(c.Rules); err != nil {
+		return err
+	}
+	if c.Games < 1 {
+		return fmt.Errorf("number of games must be at least 1")
+	}
+	switch c.Output {
+	case OutputText, OutputJSON:
+	default:
+		return fmt.Errorf("unknown output format %q, expected %s or %s", c.Output, OutputText, OutputJSON)
+	}
+	return nil
+}
+
+func splitFleet(s string) []string {
+	var fleet []string
+	for _, coord := range strings.Split(s, ",") {
+		coord = strings.TrimSpace(coord)
+		if coord != "" {
+			fleet = append(fleet, coord)
+		}
+	}
+	return fleet
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLoadPrecedence checks that the config file overrides the defaults,
+// the environment the file and flags the environment.
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	file := `{
+		"nick": "from-file",
+		"timeout": "10s",
+		"strategy": "hunt",
+		"games": 3,
+		"output": "json"
+	}`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BATTLESHIP_CONFIG", path)
+	t.Setenv("BATTLESHIP_HEADLESS", "true")
+	t.Setenv("BATTLESHIP_STRATEGY", "random")
+	t.Setenv("BATTLESHIP_GAMES", "5")
+	t.Setenv("BATTLESHIP_OUTPUT", "json")
+
+	cfg, err := Load([]string{"-games", "7", "-output", "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Default()
+	if cfg.ServerURL != want.ServerURL || cfg.PollInterval != want.PollInterval {
+		t.Errorf("server %q, poll %v, want the defaults %q, %v", cfg.ServerURL, cfg.PollInterval, want.ServerURL, want.PollInterval)
+	}
+	if cfg.Nick != "from-file" || !cfg.NickSet || cfg.Timeout.Duration() != 10*time.Second {
+		t.Errorf("nick %q (set %v), timeout %v, want them from the file", cfg.Nick, cfg.NickSet, cfg.Timeout.Duration())
+	}
+	if !cfg.Headless || cfg.Strategy != "random" {
+		t.Errorf("headless %v, strategy %q, want them from the environment", cfg.Headless, cfg.Strategy)
+	}
+	if cfg.Games != 7 || cfg.Output != OutputText {
+		t.Errorf("games %d, output %q, want them from the flags", cfg.Games, cfg.Output)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Setenv("BATTLESHIP_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{name: "games not a number", env: map[string]string{"BATTLESHIP_GAMES": "many"}},
+		{name: "headless not a bool", env: map[string]string{"BATTLESHIP_HEADLESS": "sometimes"}},
+		{name: "unknown output", env: map[string]string{"BATTLESHIP_OUTPUT": "xml"}},
+		{name: "no games", args: []string{"-games", "0"}},
+		{name: "unknown mode", args: []string{"-mode", "tournament"}},
+		{name: "invalid rules", args: []string{"-rules", "30x30"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, err := Load(tt.args); err == nil {
+				t.Error("Load returned no error")
+			}
+		})
+	}
+}
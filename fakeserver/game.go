@@ -0,0 +1,98 @@
+package fakeserver
+
+import (
+	"battleship-client/fleet"
+	gamerules "battleship-client/game"
+	"math/rand"
+)
+
+type board struct {
+	ships []gamerules.Ship
+	cells map[gamerules.Coord]int
+}
+
+func newBoard(rules gamerules.Rules, coords []string) (*board, error) {
+	ships, err := fleet.Ships(rules, coords)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &board{cells: make(map[gamerules.Coord]int, len(coords))}
+	for i, ship := range ships {
+		var current gamerules.Ship
+		for _, coord := range ship {
+			c, err := rules.ParseCoord(coord)
+			if err != nil {
+				return nil, err
+			}
+			current = append(current, c)
+			b.cells[c] = i
+		}
+		b.ships = append(b.ships, current)
+	}
+
+	return b, nil
+}
+
+// shoot applies a shot to the board and returns "miss", "hit" or "sunk".
+func (b *board) shoot(c gamerules.Coord, shots map[gamerules.Coord]bool) string {
+	shots[c] = true
+	ship, exists := b.cells[c]
+	if !exists {
+		return "miss"
+	}
+	for _, part := range b.ships[ship] {
+		if !shots[part] {
+			return "hit"
+		}
+	}
+	return "sunk"
+}
+
+func (b *board) destroyed(shots map[gamerules.Coord]bool) bool {
+	for c := range b.cells {
+		if !shots[c] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *board) sunkShip(c gamerules.Coord) gamerules.Ship {
+	return b.ships[b.cells[c]]
+}
+
+func (b *board) coords() []string {
+	var coords []string
+	for _, ship := range b.ships {
+		for _, c := range ship {
+			coords = append(coords, c.String())
+		}
+	}
+	return coords
+}
+
+// botShot picks the wpbot's next target: cells next to unsunk hits first,
+// otherwise a random cell that can still hold a ship.
+func botShot(r *rand.Rand, rules gamerules.Rules, shots map[gamerules.Coord]bool, hits map[gamerules.Coord]bool, blocked map[gamerules.Coord]bool) gamerules.Coord {
+	var candidates []gamerules.Coord
+	for c := range hits {
+		for _, offset := range gamerules.SideOffsets {
+			n := c.Add(offset)
+			if n.In(rules.Width, rules.Height) && !shots[n] && !blocked[n] {
+				candidates = append(candidates, n)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		for x := 0; x < rules.Width; x++ {
+			for y := 0; y < rules.Height; y++ {
+				c := gamerules.Coord{X: x, Y: y}
+				if !shots[c] && !blocked[c] {
+					candidates = append(candidates, c)
+				}
+			}
+		}
+	}
+	return candidates[r.Intn(len(candidates))]
+}
@@ -0,0 +1,503 @@
+// Package fakeserver implements an in-memory stand-in for the battleship
+// game server, including a wpbot opponent, a waiting list and stats.
+package fakeserver
+
+import (
+	"battleship-client/fleet"
+	gamerules "battleship-client/game"
+	api "battleship-client/http"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	mrand "math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIPrefix is the path under which the server exposes its endpoints.
+const APIPrefix = "/api"
+
+const botNick = "wpbot"
+
+type Options struct {
+	TurnTimeout time.Duration
+	WaitTimeout time.Duration
+	Seed        int64
+	// Rules are the house rules all games are played by. The zero value
+	// means the standard rules. They must leave room for a random fleet.
+	Rules gamerules.Rules
+}
+
+type Server struct {
+	mu      sync.Mutex
+	opts    Options
+	rand    *mrand.Rand
+	players map[string]*player
+	stats   map[string]*api.StatsData
+}
+
+type player struct {
+	token          string
+	nick           string
+	desc           string
+	board          *board
+	bot            bool
+	status         string
+	lastGameStatus string
+	game           *game
+	waitDeadline   time.Time
+}
+
+type game struct {
+	players  [2]*player
+	shots    [2]map[gamerules.Coord]bool
+	order    [2][]string
+	hits     [2]map[gamerules.Coord]bool
+	blocked  [2]map[gamerules.Coord]bool
+	turn     int
+	deadline time.Time
+}
+
+func New(opts Options) *Server {
+	if opts.TurnTimeout == 0 {
+		opts.TurnTimeout = 60 * time.Second
+	}
+	if opts.WaitTimeout == 0 {
+		opts.WaitTimeout = 60 * time.Second
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	if opts.Rules.Fleet == nil {
+		opts.Rules = gamerules.StandardRules()
+	}
+	return &Server{
+		opts:    opts,
+		rand:    mrand.New(mrand.NewSource(opts.Seed)),
+		players: make(map[string]*player),
+		stats:   make(map[string]*api.StatsData),
+	}
+}
+
+// NewTestServer starts a Server wrapped in httptest.Server. Clients should
+// use ts.URL + APIPrefix as their base URL.
+func NewTestServer(opts Options) *httptest.Server {
+	return httptest.NewServer(New(opts).Handler())
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(APIPrefix+"/game", s.handleGame)
+	mux.HandleFunc(APIPrefix+"/game/board", s.authorized(http.MethodGet, s.handleBoard))
+	mux.HandleFunc(APIPrefix+"/game/desc", s.authorized(http.MethodGet, s.handleDescription))
+	mux.HandleFunc(APIPrefix+"/game/fire", s.authorized(http.MethodPost, s.handleFire))
+	mux.HandleFunc(APIPrefix+"/game/list", s.handleList)
+	mux.HandleFunc(APIPrefix+"/game/refresh", s.authorized(http.MethodGet, s.handleRefresh))
+	mux.HandleFunc(APIPrefix+"/game/abandon", s.authorized(http.MethodDelete, s.handleAbandon))
+	mux.HandleFunc(APIPrefix+"/stats", s.handleStats)
+	mux.HandleFunc(APIPrefix+"/stats/", s.handlePlayerStats)
+	return mux
+}
+
+func (s *Server) handleGame(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handleInitGame(w, r)
+	case http.MethodGet:
+		s.authorized(http.MethodGet, s.handleStatus)(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) authorized(method string, handler func(http.ResponseWriter, *http.Request, *player)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		p, ok := s.players[r.Header.Get("X-Auth-Token")]
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		if p.status == "waiting" && time.Now().After(p.waitDeadline) {
+			delete(s.players, p.token)
+			writeError(w, http.StatusForbidden, "session expired")
+			return
+		}
+		if p.game != nil {
+			s.advance(p.game)
+		}
+
+		handler(w, r, p)
+	}
+}
+
+func (s *Server) handleInitGame(w http.ResponseWriter, r *http.Request) {
+	var req api.InitGameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coords := req.Coords
+	if len(coords) == 0 {
+		coords = s.randomFleet()
+	}
+	b, err := newBoard(s.opts.Rules, coords)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	nick := req.Nick
+	if nick == "" {
+		nick = fmt.Sprintf("Guest_%04d", s.rand.Intn(10000))
+	}
+
+	p := &player{
+		token: newToken(),
+		nick:  nick,
+		desc:  req.Desc,
+		board: b,
+	}
+
+	switch {
+	case req.Wpbot:
+		bot := &player{
+			nick:  botNick,
+			desc:  "Built-in bot of the local fake server",
+			board: s.mustBoard(s.randomFleet()),
+			bot:   true,
+		}
+		s.startGame(p, bot)
+	case req.TargetNick != "":
+		opponent := s.waitingPlayer(req.TargetNick)
+		if opponent == nil {
+			writeError(w, http.StatusNotFound, "player not found on waiting list")
+			return
+		}
+		s.startGame(opponent, p)
+	default:
+		p.status = "waiting"
+		p.waitDeadline = time.Now().Add(s.opts.WaitTimeout)
+	}
+
+	s.players[p.token] = p
+	w.Header().Set("X-Auth-Token", p.token)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request, p *player) {
+	res := api.StatusResponse{
+		GameStatus:     p.status,
+		LastGameStatus: p.lastGameStatus,
+		Nick:           p.nick,
+		OppShots:       []string{},
+	}
+	if g := p.game; g != nil {
+		me := g.index(p)
+		res.Opponent = g.players[1-me].nick
+		res.OppShots = append(res.OppShots, g.order[1-me]...)
+		if p.status == "game_in_progress" {
+			res.ShouldFire = g.turn == me
+			res.Timer = int(time.Until(g.deadline).Round(time.Second) / time.Second)
+		}
+	}
+	writeJSON(w, res)
+}
+
+func (s *Server) handleBoard(w http.ResponseWriter, _ *http.Request, p *player) {
+	writeJSON(w, api.BoardResponse{Board: p.board.coords()})
+}
+
+func (s *Server) handleDescription(w http.ResponseWriter, _ *http.Request, p *player) {
+	res := api.DescriptionResponse{
+		Desc: p.desc,
+		Nick: p.nick,
+	}
+	if g := p.game; g != nil {
+		opponent := g.players[1-g.index(p)]
+		res.Opponent = opponent.nick
+		res.OppDesc = opponent.desc
+	}
+	writeJSON(w, res)
+}
+
+func (s *Server) handleFire(w http.ResponseWriter, r *http.Request, p *player) {
+	var req api.FireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	g := p.game
+	if g == nil || p.status != "game_in_progress" {
+		writeError(w, http.StatusBadRequest, "game is not in progress")
+		return
+	}
+	me := g.index(p)
+	if g.turn != me {
+		writeError(w, http.StatusBadRequest, "it is not your turn")
+		return
+	}
+	target, err := s.opts.Rules.ParseCoord(req.Coord)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := s.shoot(g, me, target)
+	if result == "miss" {
+		s.advance(g)
+	}
+	writeJSON(w, api.FireResponse{Result: result})
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []api.ListResponse{}
+	now := time.Now()
+	for token, p := range s.players {
+		if p.status != "waiting" {
+			continue
+		}
+		if now.After(p.waitDeadline) {
+			delete(s.players, token)
+			continue
+		}
+		list = append(list, api.ListResponse{GameStatus: p.status, Nick: p.nick})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Nick < list[j].Nick
+	})
+	writeJSON(w, list)
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, _ *http.Request, p *player) {
+	if p.status == "waiting" {
+		p.waitDeadline = time.Now().Add(s.opts.WaitTimeout)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleAbandon(w http.ResponseWriter, _ *http.Request, p *player) {
+	if g := p.game; g != nil && p.status == "game_in_progress" {
+		s.endGame(g, 1-g.index(p))
+	}
+	delete(s.players, p.token)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ranked := s.ranked()
+	if len(ranked) > 10 {
+		ranked = ranked[:10]
+	}
+	writeJSON(w, api.StatsResponse{Stats: ranked})
+}
+
+func (s *Server) handlePlayerStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nick := strings.TrimPrefix(r.URL.Path, APIPrefix+"/stats/")
+	for _, stats := range s.ranked() {
+		if stats.Nick == nick {
+			writeJSON(w, api.PlayerStatsResponse{Stats: stats})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "player not found")
+}
+
+func (s *Server) waitingPlayer(nick string) *player {
+	now := time.Now()
+	for _, p := range s.players {
+		if p.nick == nick && p.status == "waiting" && !now.After(p.waitDeadline) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) startGame(first *player, second *player) {
+	g := &game{
+		players:  [2]*player{first, second},
+		turn:     s.rand.Intn(2),
+		deadline: time.Now().Add(s.opts.TurnTimeout),
+	}
+	for i, p := range g.players {
+		g.shots[i] = make(map[gamerules.Coord]bool)
+		g.hits[i] = make(map[gamerules.Coord]bool)
+		g.blocked[i] = make(map[gamerules.Coord]bool)
+		p.game = g
+		p.status = "game_in_progress"
+		p.lastGameStatus = ""
+	}
+	s.advance(g)
+}
+
+// advance resolves everything that happens without player input: turn
+// timeouts and wpbot moves.
+func (s *Server) advance(g *game) {
+	if g.players[0].status != "game_in_progress" {
+		return
+	}
+	if time.Now().After(g.deadline) {
+		s.endGame(g, 1-g.turn)
+		return
+	}
+	for g.players[g.turn].bot && g.players[0].status == "game_in_progress" {
+		bot := g.turn
+		target := botShot(s.rand, s.opts.Rules, g.shots[bot], g.hits[bot], g.blocked[bot])
+		s.shoot(g, bot, target)
+	}
+}
+
+func (s *Server) shoot(g *game, shooter int, target gamerules.Coord) string {
+	opponent := g.players[1-shooter]
+	if !g.shots[shooter][target] {
+		g.order[shooter] = append(g.order[shooter], target.String())
+	}
+	result := opponent.board.shoot(target, g.shots[shooter])
+
+	switch result {
+	case "miss":
+		g.turn = 1 - shooter
+	case "hit":
+		g.hits[shooter][target] = true
+	case "sunk":
+		ship := opponent.board.sunkShip(target)
+		for _, c := range ship {
+			delete(g.hits[shooter], c)
+		}
+		for _, c := range ship.Around() {
+			g.blocked[shooter][c] = true
+		}
+		if opponent.board.destroyed(g.shots[shooter]) {
+			s.endGame(g, shooter)
+			return result
+		}
+	}
+	g.deadline = time.Now().Add(s.opts.TurnTimeout)
+
+	return result
+}
+
+func (s *Server) endGame(g *game, winner int) {
+	for i, p := range g.players {
+		p.status = "ended"
+		p.lastGameStatus = "lose"
+		if i == winner {
+			p.lastGameStatus = "win"
+		}
+		if p.bot {
+			continue
+		}
+		stats, ok := s.stats[p.nick]
+		if !ok {
+			stats = &api.StatsData{Nick: p.nick}
+			s.stats[p.nick] = stats
+		}
+		stats.Games++
+		if i == winner {
+			stats.Wins++
+			stats.Points += 10
+		} else {
+			stats.Points++
+		}
+	}
+}
+
+func (s *Server) ranked() []api.StatsData {
+	ranked := make([]api.StatsData, 0, len(s.stats))
+	for _, stats := range s.stats {
+		ranked = append(ranked, *stats)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Points != ranked[j].Points {
+			return ranked[i].Points > ranked[j].Points
+		}
+		return ranked[i].Nick < ranked[j].Nick
+	})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+	return ranked
+}
+
+func (g *game) index(p *player) int {
+	if g.players[0] == p {
+		return 0
+	}
+	return 1
+}
+
+func (s *Server) mustBoard(coords []string) *board {
+	b, err := newBoard(s.opts.Rules, coords)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// randomFleet places a fleet for a player who sent none or for the wpbot.
+func (s *Server) randomFleet() []string {
+	coords, err := fleet.Random(s.rand, s.opts.Rules, fleet.Options{})
+	if err != nil {
+		panic(err)
+	}
+	return coords
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
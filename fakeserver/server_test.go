@@ -0,0 +1,301 @@
+package fakeserver
+
+import (
+	gamerules "battleship-client/game"
+	api "battleship-client/http"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// standardFleet is a valid layout of the standard fleet. J10 is empty.
+var standardFleet = []string{
+	"A1", "A2", "A3", "A4",
+	"C1", "C2", "C3",
+	"E1", "E2", "E3",
+	"G1", "G2",
+	"I1", "I2",
+	"A6", "A7",
+	"C5",
+	"E5",
+	"G5",
+	"I5",
+}
+
+func newClient(t *testing.T, opts Options) func() *api.Client {
+	t.Helper()
+	ts := NewTestServer(opts)
+	t.Cleanup(ts.Close)
+	return func() *api.Client {
+		return api.NewClient(ts.URL+APIPrefix, time.Second)
+	}
+}
+
+func mustStatus(t *testing.T, c *api.Client) *api.StatusResponse {
+	t.Helper()
+	status, err := c.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	return status
+}
+
+// startGame starts a game between alice, waiting for an opponent, and bob,
+// who challenges her. The returned clients are ordered by turn.
+func startGame(t *testing.T, client func() *api.Client) (first *api.Client, second *api.Client) {
+	t.Helper()
+	ctx := context.Background()
+	alice, bob := client(), client()
+	if err := alice.InitGame(ctx, standardFleet, "", "alice", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.InitGame(ctx, standardFleet, "", "bob", "alice", false); err != nil {
+		t.Fatal(err)
+	}
+	if mustStatus(t, alice).ShouldFire {
+		return alice, bob
+	}
+	return bob, alice
+}
+
+func TestTurnOrder(t *testing.T) {
+	ctx := context.Background()
+	first, second := startGame(t, newClient(t, Options{Seed: 1}))
+
+	for _, c := range []*api.Client{first, second} {
+		if status := mustStatus(t, c); status.GameStatus != "game_in_progress" {
+			t.Fatalf("game status is %q, want game_in_progress", status.GameStatus)
+		}
+	}
+	if mustStatus(t, second).ShouldFire {
+		t.Fatal("both players should fire")
+	}
+
+	if _, err := second.Fire(ctx, "A1"); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("firing out of turn returned %v, want ErrBadRequest", err)
+	}
+
+	res, err := first.Fire(ctx, "A1")
+	if err != nil || res.Result != "hit" {
+		t.Fatalf("Fire(A1) = %v, %v, want a hit", res, err)
+	}
+	if !mustStatus(t, first).ShouldFire {
+		t.Error("the turn passed after a hit")
+	}
+	res, err = first.Fire(ctx, "J10")
+	if err != nil || res.Result != "miss" {
+		t.Fatalf("Fire(J10) = %v, %v, want a miss", res, err)
+	}
+	if mustStatus(t, first).ShouldFire {
+		t.Error("the turn did not pass after a miss")
+	}
+	status := mustStatus(t, second)
+	if !status.ShouldFire {
+		t.Error("the opponent's turn did not start after a miss")
+	}
+	if len(status.OppShots) != 2 || status.OppShots[0] != "A1" || status.OppShots[1] != "J10" {
+		t.Errorf("opponent shots = %v, want [A1 J10]", status.OppShots)
+	}
+
+	res, err = second.Fire(ctx, "C5")
+	if err != nil || res.Result != "sunk" {
+		t.Errorf("Fire(C5) = %v, %v, want a ship of length 1 sunk", res, err)
+	}
+}
+
+func TestTurnTimeout(t *testing.T) {
+	first, second := startGame(t, newClient(t, Options{Seed: 1, TurnTimeout: 50 * time.Millisecond}))
+	time.Sleep(100 * time.Millisecond)
+
+	for c, want := range map[*api.Client]string{first: "lose", second: "win"} {
+		status := mustStatus(t, c)
+		if status.GameStatus != "ended" || status.LastGameStatus != want {
+			t.Errorf("after a timeout the game is %q, %q, want ended, %q", status.GameStatus, status.LastGameStatus, want)
+		}
+		if status.ShouldFire {
+			t.Error("should fire after the game ended")
+		}
+	}
+
+	if _, err := second.Fire(context.Background(), "A1"); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("firing after the game ended returned %v, want ErrBadRequest", err)
+	}
+}
+
+func TestWpbot(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, Options{Seed: 1})()
+	if err := c.InitGame(ctx, nil, "", "tester", "", true); err != nil {
+		t.Fatal(err)
+	}
+	desc, err := c.Description(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Opponent != botNick {
+		t.Errorf("opponent is %q, want %q", desc.Opponent, botNick)
+	}
+	board, err := c.Board(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(board) != 20 {
+		t.Errorf("random board has %d fields, want 20", len(board))
+	}
+
+	// Fire at every field in order. The bot moves as soon as the turn
+	// passes to it.
+	rules := gamerules.StandardRules()
+	next := 0
+	status := mustStatus(t, c)
+	for status.GameStatus == "game_in_progress" {
+		if !status.ShouldFire {
+			t.Fatal("the bot did not fire in its turn")
+		}
+		target := gamerules.Coord{X: next % rules.Width, Y: next / rules.Width}
+		next++
+		if _, err := c.Fire(ctx, target.String()); err != nil {
+			t.Fatalf("Fire(%s): %v", target, err)
+		}
+		status = mustStatus(t, c)
+	}
+
+	if status.GameStatus != "ended" || (status.LastGameStatus != "win" && status.LastGameStatus != "lose") {
+		t.Fatalf("game ended with %q, %q", status.GameStatus, status.LastGameStatus)
+	}
+	stats, err := c.PlayerStats(ctx, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stats.Games != 1 {
+		t.Errorf("%d games counted, want 1", stats.Stats.Games)
+	}
+	if _, err := c.PlayerStats(ctx, botNick); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("the bot has stats: %v", err)
+	}
+}
+
+func TestWaitingList(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, Options{Seed: 1, WaitTimeout: 100 * time.Millisecond})
+	alice, carol := client(), client()
+	if err := alice.InitGame(ctx, standardFleet, "", "alice", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := carol.InitGame(ctx, standardFleet, "", "carol", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if status := mustStatus(t, alice); status.GameStatus != "waiting" {
+		t.Errorf("game status is %q, want waiting", status.GameStatus)
+	}
+
+	list, err := alice.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*list) != 2 || (*list)[0].Nick != "alice" || (*list)[1].Nick != "carol" {
+		t.Errorf("waiting list = %v, want alice and carol", *list)
+	}
+
+	if err := client().InitGame(ctx, standardFleet, "", "bob", "dave", false); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("challenging a player who is not waiting returned %v, want ErrNotFound", err)
+	}
+
+	// Alice keeps her place by refreshing, Carol's runs out.
+	for i := 0; i < 4; i++ {
+		time.Sleep(40 * time.Millisecond)
+		if err := alice.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+	}
+	list, err = alice.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*list) != 1 || (*list)[0].Nick != "alice" {
+		t.Errorf("waiting list = %v, want only alice", *list)
+	}
+	if _, err := carol.Status(ctx); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("status after the wait timed out returned %v, want ErrUnauthorized", err)
+	}
+
+	if err := client().InitGame(ctx, standardFleet, "", "bob", "alice", false); err != nil {
+		t.Fatal(err)
+	}
+	if status := mustStatus(t, alice); status.GameStatus != "game_in_progress" || status.Opponent != "bob" {
+		t.Errorf("after being challenged the game is %q against %q", status.GameStatus, status.Opponent)
+	}
+	list, err = alice.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*list) != 0 {
+		t.Errorf("waiting list = %v, want it empty", *list)
+	}
+}
+
+func TestAbandonAndStats(t *testing.T) {
+	ctx := context.Background()
+	first, second := startGame(t, newClient(t, Options{Seed: 1}))
+	winner := mustStatus(t, second).Nick
+
+	if err := first.Abandon(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Status(ctx); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("status after abandoning returned %v, want ErrUnauthorized", err)
+	}
+	if status := mustStatus(t, second); status.GameStatus != "ended" || status.LastGameStatus != "win" {
+		t.Errorf("the opponent's game is %q, %q, want ended, win", status.GameStatus, status.LastGameStatus)
+	}
+
+	stats, err := second.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Stats) != 2 {
+		t.Fatalf("stats = %v, want both players", stats.Stats)
+	}
+	top := stats.Stats[0]
+	if top.Nick != winner || top.Rank != 1 || top.Wins != 1 || top.Games != 1 || top.Points <= stats.Stats[1].Points {
+		t.Errorf("top of the ranking is %+v, want %s with the win", top, winner)
+	}
+	if _, err := second.PlayerStats(ctx, "nobody"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("stats of an unknown player returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestHouseRules(t *testing.T) {
+	rules, err := gamerules.ParseRules("12x12:5,4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	client := newClient(t, Options{Seed: 1, Rules: rules})
+
+	if err := client().InitGame(ctx, standardFleet, "", "", "", true); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("a standard fleet returned %v, want ErrBadRequest", err)
+	}
+
+	c := client()
+	if err := c.InitGame(ctx, nil, "", "", "", true); err != nil {
+		t.Fatal(err)
+	}
+	board, err := c.Board(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(board) != 9 {
+		t.Errorf("random board has %d fields, want 9", len(board))
+	}
+	for !mustStatus(t, c).ShouldFire {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := c.Fire(ctx, "M1"); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("firing outside the board returned %v, want ErrBadRequest", err)
+	}
+	if _, err := c.Fire(ctx, "L12"); err != nil {
+		t.Errorf("firing at the far corner returned %v", err)
+	}
+}
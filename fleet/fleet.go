@@ -0,0 +1,236 @@
+// Package fleet validates and generates fleet layouts: straight ships of the
+// lengths given by the game rules, by default 4, 3, 3, 2, 2, 2, 1, 1, 1, 1 on
+// a 10x10 board, that do not touch each other, not even diagonally.
+package fleet
+
+import (
+	"battleship-client/game"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// randomAttempts is how many times Random tries to place a fleet before it
+// gives up.
+const randomAttempts = 200
+
+type Options struct {
+	// AvoidEdges keeps ships off the outermost rows and columns.
+	AvoidEdges bool
+	// SpreadOut prefers placements far away from ships placed before.
+	SpreadOut bool
+}
+
+// Validate checks that coords form a complete fleet under rules.
+func Validate(rules game.Rules, coords []string) error {
+	_, err := Ships(rules, coords)
+	return err
+}
+
+// Ships validates coords against rules and groups them into ships, longest
+// first.
+func Ships(rules game.Rules, coords []string) ([][]string, error) {
+	cells := make(map[game.Coord]bool, len(coords))
+	for _, coord := range coords {
+		c, err := rules.ParseCoord(coord)
+		if err != nil {
+			return nil, err
+		}
+		if cells[c] {
+			return nil, fmt.Errorf("duplicated coordinate %s", c)
+		}
+		cells[c] = true
+	}
+
+	var ships []game.Ship
+	visited := make(map[game.Coord]bool, len(cells))
+	for _, coord := range coords {
+		c, _ := rules.ParseCoord(coord)
+		if visited[c] {
+			continue
+		}
+		var ship game.Ship
+		visited[c] = true
+		toVisit := []game.Coord{c}
+		for len(toVisit) > 0 {
+			current := toVisit[0]
+			toVisit = toVisit[1:]
+			ship = append(ship, current)
+			for _, offset := range game.AllOffsets {
+				n := current.Add(offset)
+				if cells[n] && !visited[n] {
+					visited[n] = true
+					toVisit = append(toVisit, n)
+				}
+			}
+		}
+		if !straight(ship) {
+			return nil, fmt.Errorf("ship at %s is not straight or touches another ship", ship[0])
+		}
+		ships = append(ships, ship)
+	}
+
+	counts := make(map[int]int)
+	for _, ship := range ships {
+		counts[len(ship)]++
+	}
+	expected := rules.Fleet
+	for size := range counts {
+		if expected[size] == 0 {
+			return nil, fmt.Errorf("ships of length %d are not allowed", size)
+		}
+	}
+	for size, count := range expected {
+		if counts[size] != count {
+			return nil, fmt.Errorf("expected %d ships of length %d, got %d", count, size, counts[size])
+		}
+	}
+
+	sort.SliceStable(ships, func(i, j int) bool {
+		return len(ships[i]) > len(ships[j])
+	})
+	return toCoords(ships), nil
+}
+
+func toCoords(ships []game.Ship) [][]string {
+	result := make([][]string, 0, len(ships))
+	for _, ship := range ships {
+		coords := make([]string, 0, len(ship))
+		for _, c := range ship {
+			coords = append(coords, c.String())
+		}
+		result = append(result, coords)
+	}
+	return result
+}
+
+func straight(ship game.Ship) bool {
+	minX, maxX, minY, maxY := ship[0].X, ship[0].X, ship[0].Y, ship[0].Y
+	for _, c := range ship {
+		if c.X < minX {
+			minX = c.X
+		}
+		if c.X > maxX {
+			maxX = c.X
+		}
+		if c.Y < minY {
+			minY = c.Y
+		}
+		if c.Y > maxY {
+			maxY = c.Y
+		}
+	}
+	if minX != maxX && minY != maxY {
+		return false
+	}
+	return (maxX-minX)+(maxY-minY)+1 == len(ship)
+}
+
+// Random generates a valid fleet under rules. If the options cannot be
+// satisfied they are dropped one by one. It fails if the ships do not fit on
+// the board.
+func Random(r *rand.Rand, rules game.Rules, opts Options) ([]string, error) {
+	for attempt := 0; attempt < randomAttempts; attempt++ {
+		if attempt == randomAttempts/4 {
+			opts.AvoidEdges = false
+		}
+		if ships, ok := place(r, rules, opts); ok {
+			var coords []string
+			for _, ship := range toCoords(ships) {
+				coords = append(coords, ship...)
+			}
+			return coords, nil
+		}
+	}
+	return nil, fmt.Errorf("could not fit the fleet %s on the board", rules)
+}
+
+func place(r *rand.Rand, rules game.Rules, opts Options) ([]game.Ship, bool) {
+	occupied := make(map[game.Coord]bool)
+	var ships []game.Ship
+	for _, size := range rules.Ships() {
+		candidates := placements(rules, size, occupied, opts.AvoidEdges)
+		if len(candidates) == 0 {
+			return nil, false
+		}
+
+		ship := candidates[r.Intn(len(candidates))]
+		if opts.SpreadOut && len(ships) > 0 {
+			best := -1
+			for i := 0; i < 20; i++ {
+				candidate := candidates[r.Intn(len(candidates))]
+				if d := distance(candidate, ships); d > best {
+					best = d
+					ship = candidate
+				}
+			}
+		}
+
+		for _, c := range ship {
+			occupied[c] = true
+		}
+		ships = append(ships, ship)
+	}
+	return ships, true
+}
+
+// placements returns every position of a ship of the given size that does
+// not overlap or touch the occupied cells.
+func placements(rules game.Rules, size int, occupied map[game.Coord]bool, avoidEdges bool) []game.Ship {
+	var ships []game.Ship
+	for _, ship := range game.NewBoard(rules.Width, rules.Height).Placements(size) {
+		if avoidEdges && onEdge(rules, ship) {
+			continue
+		}
+		if canPlace(occupied, ship) {
+			ships = append(ships, ship)
+		}
+	}
+	return ships
+}
+
+func onEdge(rules game.Rules, ship game.Ship) bool {
+	for _, c := range ship {
+		if c.X == 0 || c.Y == 0 || c.X == rules.Width-1 || c.Y == rules.Height-1 {
+			return true
+		}
+	}
+	return false
+}
+
+func canPlace(occupied map[game.Coord]bool, ship game.Ship) bool {
+	for _, c := range ship {
+		if occupied[c] {
+			return false
+		}
+	}
+	for _, c := range ship.Around() {
+		if occupied[c] {
+			return false
+		}
+	}
+	return true
+}
+
+func distance(ship game.Ship, ships []game.Ship) int {
+	best := math.MaxInt
+	for _, other := range ships {
+		for _, c := range ship {
+			for _, o := range other {
+				d := abs(c.X-o.X) + abs(c.Y-o.Y)
+				if d < best {
+					best = d
+				}
+			}
+		}
+	}
+	return best
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,127 @@
+package fleet
+
+import (
+	"battleship-client/game"
+	"math/rand"
+	"testing"
+)
+
+// standard is a valid layout of the standard fleet.
+var standard = []string{
+	"A1", "A2", "A3", "A4",
+	"C1", "C2", "C3",
+	"E1", "E2", "E3",
+	"G1", "G2",
+	"I1", "I2",
+	"A6", "A7",
+	"C5",
+	"E5",
+	"G5",
+	"I5",
+}
+
+// replace returns standard with the fields in remove swapped for add.
+func replace(remove []string, add ...string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, c := range remove {
+		removed[c] = true
+	}
+	var coords []string
+	for _, c := range standard {
+		if !removed[c] {
+			coords = append(coords, c)
+		}
+	}
+	return append(coords, add...)
+}
+
+func TestShips(t *testing.T) {
+	ships, err := Ships(game.StandardRules(), standard)
+	if err != nil {
+		t.Fatalf("Ships returned error for a valid fleet: %v", err)
+	}
+	want := []int{4, 3, 3, 2, 2, 2, 1, 1, 1, 1}
+	if len(ships) != len(want) {
+		t.Fatalf("Ships returned %d ships, want %d", len(ships), len(want))
+	}
+	for i, ship := range ships {
+		if len(ship) != want[i] {
+			t.Errorf("ship %d has length %d, want %d", i, len(ship), want[i])
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		coords  []string
+		wantErr bool
+	}{
+		{name: "standard fleet", coords: standard},
+		{name: "lowercase coordinates", coords: replace([]string{"I5"}, "i5")},
+		{name: "L-shaped ship", coords: replace([]string{"A1", "A2", "A3", "A4"}, "J7", "J8", "J9", "I9"), wantErr: true},
+		{name: "ships touching diagonally", coords: replace([]string{"I5"}, "B8"), wantErr: true},
+		// G1-G2 moved below A6-A7 make a single ship of length 4.
+		{name: "ships end to end", coords: replace([]string{"G1", "G2"}, "A8", "A9"), wantErr: true},
+		{name: "missing ship", coords: replace([]string{"I5"}), wantErr: true},
+		{name: "extra ship", coords: replace(nil, "J10"), wantErr: true},
+		{name: "duplicated field", coords: replace([]string{"I5"}, "C5"), wantErr: true},
+		{name: "field outside the board", coords: replace([]string{"I5"}, "K1"), wantErr: true},
+		{name: "empty", coords: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := Validate(game.StandardRules(), tt.coords)
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: Validate returned no error", tt.name)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: Validate returned error: %v", tt.name, err)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	house, err := game.ParseRules("12x12:5,4,4,3,3,2,2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := []Options{
+		{},
+		{AvoidEdges: true},
+		{SpreadOut: true},
+		{AvoidEdges: true, SpreadOut: true},
+	}
+
+	for _, rules := range []game.Rules{game.StandardRules(), house} {
+		cells := 0
+		for _, length := range rules.Ships() {
+			cells += length
+		}
+		for _, opts := range options {
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 50; i++ {
+				coords, err := Random(r, rules, opts)
+				if err != nil {
+					t.Fatalf("%s %+v: Random returned error: %v", rules, opts, err)
+				}
+				if len(coords) != cells {
+					t.Errorf("%s %+v: Random returned %d fields, want %d", rules, opts, len(coords), cells)
+				}
+				if err := Validate(rules, coords); err != nil {
+					t.Errorf("%s %+v: Random returned an invalid fleet %v: %v", rules, opts, coords, err)
+				}
+			}
+		}
+	}
+}
+
+func TestRandomDoesNotFit(t *testing.T) {
+	rules, err := game.ParseRules("3x3:2,2,2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coords, err := Random(rand.New(rand.NewSource(1)), rules, Options{}); err == nil {
+		t.Errorf("Random returned %v for a fleet that does not fit", coords)
+	}
+}
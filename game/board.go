@@ -0,0 +1,167 @@
+package game
+
+// Cell is the state of a single field on a board.
+type Cell int
+
+const (
+	// Empty is a field that was not shot at. On the opponent's board it may
+	// still hide a ship.
+	Empty Cell = iota
+	// Occupied is a part of a ship that was not hit.
+	Occupied
+	Hit
+	Miss
+	// Sunk is a part of a ship that was sunk.
+	Sunk
+)
+
+// IsShip reports whether the field is known to be a part of a ship.
+func (c Cell) IsShip() bool {
+	return c == Occupied || c == Hit || c == Sunk
+}
+
+type Board struct {
+	width  int
+	height int
+	cells  []Cell
+}
+
+func NewBoard(width, height int) *Board {
+	return &Board{
+		width:  width,
+		height: height,
+		cells:  make([]Cell, width*height),
+	}
+}
+
+func (b *Board) Width() int {
+	return b.width
+}
+
+func (b *Board) Height() int {
+	return b.height
+}
+
+func (b *Board) InBounds(c Coord) bool {
+	return c.In(b.width, b.height)
+}
+
+// At returns the state of the field at c. Fields outside the board are
+// Empty.
+func (b *Board) At(c Coord) Cell {
+	if !b.InBounds(c) {
+		return Empty
+	}
+	return b.cells[c.X*b.height+c.Y]
+}
+
+// Set changes the state of the field at c. Fields outside the board are
+// ignored.
+func (b *Board) Set(c Coord, cell Cell) {
+	if !b.InBounds(c) {
+		return
+	}
+	b.cells[c.X*b.height+c.Y] = cell
+}
+
+// Coords returns all fields of the board, column by column.
+func (b *Board) Coords() []Coord {
+	coords := make([]Coord, 0, len(b.cells))
+	for x := 0; x < b.width; x++ {
+		for y := 0; y < b.height; y++ {
+			coords = append(coords, Coord{x, y})
+		}
+	}
+	return coords
+}
+
+// Find returns the fields in the given state, column by column.
+func (b *Board) Find(cell Cell) []Coord {
+	var coords []Coord
+	for _, c := range b.Coords() {
+		if b.At(c) == cell {
+			coords = append(coords, c)
+		}
+	}
+	return coords
+}
+
+// ShipAt returns the ship that has a part at c: the fields known to be ship
+// parts that are connected to c by their sides. It returns nil if there is no
+// ship part at c.
+func (b *Board) ShipAt(c Coord) Ship {
+	if !b.At(c).IsShip() {
+		return nil
+	}
+	var ship Ship
+	toVisit := []Coord{c}
+	visited := map[Coord]bool{c: true}
+	for len(toVisit) > 0 {
+		current := toVisit[0]
+		toVisit = toVisit[1:]
+		ship = append(ship, current)
+		for _, offset := range SideOffsets {
+			next := current.Add(offset)
+			if visited[next] || !b.At(next).IsShip() {
+				continue
+			}
+			visited[next] = true
+			toVisit = append(toVisit, next)
+		}
+	}
+	return ship
+}
+
+// Sink marks ship as sunk.
+func (b *Board) Sink(ship Ship) {
+	for _, c := range ship {
+		b.Set(c, Sunk)
+	}
+}
+
+// MarkAround marks the empty fields around ship as misses, since ships can't
+// touch each other.
+func (b *Board) MarkAround(ship Ship) {
+	for _, c := range ship.Around() {
+		if b.At(c) == Empty {
+			b.Set(c, Miss)
+		}
+	}
+}
+
+// Placements returns every straight line of length fields on the board.
+func (b *Board) Placements(length int) []Ship {
+	var ships []Ship
+	directions := []Coord{{X: 1, Y: 0}, {X: 0, Y: 1}}
+	if length == 1 {
+		directions = directions[:1]
+	}
+	for _, d := range directions {
+		for x := 0; x+d.X*(length-1) < b.width; x++ {
+			for y := 0; y+d.Y*(length-1) < b.height; y++ {
+				ship := make(Ship, length)
+				for i := range ship {
+					ship[i] = Coord{X: x + d.X*i, Y: y + d.Y*i}
+				}
+				ships = append(ships, ship)
+			}
+		}
+	}
+	return ships
+}
+
+// Fits reports whether a ship that was not sunk yet can lie at ship: only on
+// unknown fields or hits, and not touching any other hit.
+func (b *Board) Fits(ship Ship) bool {
+	for _, c := range ship {
+		if cell := b.At(c); cell != Empty && cell != Hit {
+			return false
+		}
+	}
+	for _, c := range ship.Around() {
+		if b.At(c) == Hit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Coord is a field on the board. X is the column, shown as a letter, and Y
+// the row, shown as a number starting at 1.
+type Coord struct {
+	X int
+	Y int
+}
+
+// ParseCoord parses a coordinate such as "A1" or "j10": a column letter
+// followed by a row number, both within the standard board. Surrounding
+// spaces and lowercase letters are accepted.
+func ParseCoord(s string) (Coord, error) {
+	return parseCoord(s, Size, Size)
+}
+
+func parseCoord(s string, width, height int) (Coord, error) {
+	coord := strings.ToUpper(strings.TrimSpace(s))
+	if len(coord) < 2 {
+		return Coord{}, fmt.Errorf("invalid coordinate %q: expected a column letter and a row number, e.g. A1", s)
+	}
+	column, row := coord[0], coord[1:]
+	if column < 'A' || column > 'Z' {
+		return Coord{}, fmt.Errorf("invalid coordinate %q: column must be a letter", s)
+	}
+	for i := 0; i < len(row); i++ {
+		if row[i] < '0' || row[i] > '9' {
+			return Coord{}, fmt.Errorf("invalid coordinate %q: row must be a number", s)
+		}
+	}
+	n, err := strconv.Atoi(row)
+	if err != nil || row[0] == '0' {
+		return Coord{}, fmt.Errorf("invalid coordinate %q: row must be a number from 1", s)
+	}
+
+	c := Coord{X: int(column - 'A'), Y: n - 1}
+	if !c.In(width, height) {
+		last := Coord{X: width - 1, Y: height - 1}
+		return Coord{}, fmt.Errorf("coordinate %q is outside the board, expected A1 to %s", s, last)
+	}
+	return c, nil
+}
+
+func (c Coord) String() string {
+	return fmt.Sprintf("%c%d", 'A'+c.X, c.Y+1)
+}
+
+// In reports whether c lies on a board of the given size.
+func (c Coord) In(width, height int) bool {
+	return c.X >= 0 && c.X < width && c.Y >= 0 && c.Y < height
+}
+
+func (c Coord) Add(offset Coord) Coord {
+	return Coord{X: c.X + offset.X, Y: c.Y + offset.Y}
+}
+
+// SideOffsets lead to the fields sharing a side with a field.
+var SideOffsets = []Coord{
+	{1, 0},
+	{0, 1},
+	{-1, 0},
+	{0, -1},
+}
+
+// AllOffsets lead to all fields touching a field, including diagonally.
+var AllOffsets = []Coord{
+	{1, 0},
+	{0, 1},
+	{1, 1},
+	{-1, 0},
+	{0, -1},
+	{-1, -1},
+	{1, -1},
+	{-1, 1},
+}
@@ -0,0 +1,92 @@
+package game
+
+import "testing"
+
+func TestParseCoord(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Coord
+		wantErr bool
+	}{
+		{in: "A1", want: Coord{X: 0, Y: 0}},
+		{in: "a1", want: Coord{X: 0, Y: 0}},
+		{in: " j10 ", want: Coord{X: 9, Y: 9}},
+		{in: "J10", want: Coord{X: 9, Y: 9}},
+		{in: "C7", want: Coord{X: 2, Y: 6}},
+		{in: "A11", wantErr: true},
+		{in: "AZZ", wantErr: true},
+		{in: "K1", wantErr: true},
+		{in: "A0", wantErr: true},
+		{in: "A01", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "A", wantErr: true},
+		{in: "1A", wantErr: true},
+		{in: "A-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCoord(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseCoord(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseCoord(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCoord(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRulesParseCoord(t *testing.T) {
+	rules, err := ParseRules("12x8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in      string
+		want    Coord
+		wantErr bool
+	}{
+		{in: "A1", want: Coord{X: 0, Y: 0}},
+		{in: "L8", want: Coord{X: 11, Y: 7}},
+		{in: "k3", want: Coord{X: 10, Y: 2}},
+		{in: "M1", wantErr: true},
+		{in: "A9", wantErr: true},
+		{in: "L10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := rules.ParseCoord(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseCoord(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseCoord(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCoord(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCoordString(t *testing.T) {
+	for _, s := range []string{"A1", "J10", "L12", "Z26"} {
+		c, err := parseCoord(s, maxSize, maxSize)
+		if err != nil {
+			t.Fatalf("parseCoord(%q) returned error: %v", s, err)
+		}
+		if got := c.String(); got != s {
+			t.Errorf("Coord %v String() = %q, want %q", c, got, s)
+		}
+	}
+}
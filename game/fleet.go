@@ -0,0 +1,86 @@
+package game
+
+import "sort"
+
+// Ship is the list of fields a ship takes.
+type Ship []Coord
+
+// Around returns the fields touching the ship, including diagonally. Some of
+// them may lie outside the board.
+func (s Ship) Around() []Coord {
+	in := make(map[Coord]bool, len(s))
+	for _, c := range s {
+		in[c] = true
+	}
+	var around []Coord
+	seen := make(map[Coord]bool)
+	for _, c := range s {
+		for _, offset := range AllOffsets {
+			n := c.Add(offset)
+			if in[n] || seen[n] {
+				continue
+			}
+			seen[n] = true
+			around = append(around, n)
+		}
+	}
+	return around
+}
+
+// Covers reports whether all fields of other are a part of s.
+func (s Ship) Covers(other Ship) bool {
+	in := make(map[Coord]bool, len(s))
+	for _, c := range s {
+		in[c] = true
+	}
+	for _, c := range other {
+		if !in[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// Fleet counts ships by their length.
+type Fleet map[int]int
+
+// StandardFleet returns the fleet used by the server: one ship of length 4,
+// two of length 3, three of length 2 and four of length 1.
+func StandardFleet() Fleet {
+	return Fleet{
+		4: 1,
+		3: 2,
+		2: 3,
+		1: 4,
+	}
+}
+
+func (f Fleet) Clone() Fleet {
+	clone := make(Fleet, len(f))
+	for length, count := range f {
+		clone[length] = count
+	}
+	return clone
+}
+
+// Lengths returns the ship lengths in the fleet, longest first.
+func (f Fleet) Lengths() []int {
+	var lengths []int
+	for length, count := range f {
+		if count > 0 {
+			lengths = append(lengths, length)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+	return lengths
+}
+
+// Empty reports whether no ships are left in the fleet.
+func (f Fleet) Empty() bool {
+	for _, count := range f {
+		if count > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+// Package game models a game of battleships independently of any front end:
+// both boards, the opponent's remaining fleet and the rules for shots,
+// sinking and the no-touching rule.
+package game
+
+// Result is the outcome of a shot as reported by the server.
+type Result string
+
+const (
+	ResultMiss Result = "miss"
+	ResultHit  Result = "hit"
+	ResultSunk Result = "sunk"
+)
+
+// Size is the width and height of the standard board.
+const Size = 10
+
+// Game is the state of a game as seen by one player.
+type Game struct {
+	Rules Rules
+	// Player holds our ships and the opponent's shots at them.
+	Player *Board
+	// Opponent holds our shots at the opponent and what they revealed.
+	Opponent *Board
+	// Remaining counts the opponent's ships that were not sunk yet.
+	Remaining     Fleet
+	OpponentShots []Coord
+	ShotsFired    int
+	ShotsHit      int
+	// Turns counts our turns in which we fired at least once.
+	Turns int
+	// RuledOut are the fields of the opponent's board marked as misses
+	// without being shot at: the ones around sunk ships and the ones no
+	// remaining ship fits in.
+	RuledOut []Coord
+	// Certain are the unknown fields of the opponent's board that must be a
+	// part of a ship.
+	Certain []Coord
+
+	lastResult Result
+}
+
+// New starts a game played by rules with our ships placed at ships.
+func New(rules Rules, ships []Coord) *Game {
+	g := &Game{
+		Rules:     rules,
+		Player:    NewBoard(rules.Width, rules.Height),
+		Opponent:  NewBoard(rules.Width, rules.Height),
+		Remaining: rules.Fleet.Clone(),
+	}
+	for _, c := range ships {
+		g.Player.Set(c, Occupied)
+	}
+	return g
+}
+
+// Shot records the result of our shot at c. It returns the sunk ship if the
+// shot sunk one.
+func (g *Game) Shot(c Coord, result Result) Ship {
+	if g.ShotsFired == 0 || g.lastResult == ResultMiss {
+		g.Turns++
+	}
+	g.lastResult = result
+	g.ShotsFired++
+	defer g.infer()
+	if result == ResultMiss {
+		g.Opponent.Set(c, Miss)
+		return nil
+	}
+
+	g.ShotsHit++
+	g.Opponent.Set(c, Hit)
+	if result != ResultSunk {
+		return nil
+	}
+	ship := g.Opponent.ShipAt(c)
+	g.Opponent.Sink(ship)
+	g.ruleOut(ship.Around())
+	g.Remaining[len(ship)]--
+	return ship
+}
+
+// infer rules out the fields no remaining ship fits in and updates the
+// fields that must be a ship.
+func (g *Game) infer() {
+	inference := Infer(g.Opponent, g.Remaining)
+	g.ruleOut(inference.Impossible)
+	g.Certain = inference.Certain
+}
+
+func (g *Game) ruleOut(coords []Coord) {
+	for _, c := range coords {
+		if g.Opponent.InBounds(c) && g.Opponent.At(c) == Empty {
+			g.Opponent.Set(c, Miss)
+			g.RuledOut = append(g.RuledOut, c)
+		}
+	}
+}
+
+// OpponentShot applies the opponent's shot at c to our board.
+func (g *Game) OpponentShot(c Coord) Result {
+	g.OpponentShots = append(g.OpponentShots, c)
+	switch g.Player.At(c) {
+	case Empty:
+		g.Player.Set(c, Miss)
+	case Occupied:
+		g.Player.Set(c, Hit)
+		ship := g.Player.ShipAt(c)
+		for _, part := range ship {
+			if g.Player.At(part) == Occupied {
+				return ResultHit
+			}
+		}
+		g.Player.Sink(ship)
+	}
+
+	switch g.Player.At(c) {
+	case Sunk:
+		return ResultSunk
+	case Hit:
+		return ResultHit
+	}
+	return ResultMiss
+}
+
+// FleetSunk reports whether all of the opponent's ships were sunk.
+func (g *Game) FleetSunk() bool {
+	return g.Remaining.Empty()
+}
@@ -0,0 +1,185 @@
+package game
+
+import (
+	"sort"
+	"testing"
+)
+
+func mustCoord(t *testing.T, s string) Coord {
+	t.Helper()
+	c, err := ParseCoord(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func mustCoords(t *testing.T, coords ...string) []Coord {
+	t.Helper()
+	result := make([]Coord, 0, len(coords))
+	for _, s := range coords {
+		result = append(result, mustCoord(t, s))
+	}
+	return result
+}
+
+// sameCoords reports whether a and b hold the same fields in any order.
+func sameCoords(a, b []Coord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sorted := func(coords []Coord) []string {
+		s := make([]string, 0, len(coords))
+		for _, c := range coords {
+			s = append(s, c.String())
+		}
+		sort.Strings(s)
+		return s
+	}
+	sa, sb := sorted(a), sorted(b)
+	for i := range sa {
+		if sa[i] != sb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBoardShipAt(t *testing.T) {
+	b := NewBoard(Size, Size)
+	for _, c := range mustCoords(t, "B2", "B3") {
+		b.Set(c, Hit)
+	}
+	b.Set(mustCoord(t, "B4"), Sunk)
+	b.Set(mustCoord(t, "C5"), Hit)
+	b.Set(mustCoord(t, "B5"), Miss)
+
+	tests := []struct {
+		at   string
+		want []Coord
+	}{
+		{at: "B2", want: mustCoords(t, "B2", "B3", "B4")},
+		{at: "B4", want: mustCoords(t, "B2", "B3", "B4")},
+		// Fields touching only diagonally are a different ship.
+		{at: "C5", want: mustCoords(t, "C5")},
+		{at: "B5", want: nil},
+		{at: "J10", want: nil},
+	}
+	for _, tt := range tests {
+		got := b.ShipAt(mustCoord(t, tt.at))
+		if !sameCoords(got, tt.want) {
+			t.Errorf("ShipAt(%s) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestGameShot(t *testing.T) {
+	g := New(StandardRules(), nil)
+
+	steps := []struct {
+		coord      string
+		result     Result
+		wantSunk   int
+		wantTurns  int
+		wantFired  int
+		wantHit    int
+		wantRemain Fleet
+	}{
+		{coord: "A1", result: ResultMiss, wantTurns: 1, wantFired: 1, wantRemain: StandardFleet()},
+		{coord: "C1", result: ResultHit, wantTurns: 2, wantFired: 2, wantHit: 1, wantRemain: StandardFleet()},
+		{coord: "C2", result: ResultSunk, wantSunk: 2, wantTurns: 2, wantFired: 3, wantHit: 2, wantRemain: Fleet{4: 1, 3: 2, 2: 2, 1: 4}},
+		// A miss after a hit ends the turn, the next shot starts a new one.
+		{coord: "E5", result: ResultMiss, wantTurns: 2, wantFired: 4, wantHit: 2, wantRemain: Fleet{4: 1, 3: 2, 2: 2, 1: 4}},
+		{coord: "J10", result: ResultSunk, wantSunk: 1, wantTurns: 3, wantFired: 5, wantHit: 3, wantRemain: Fleet{4: 1, 3: 2, 2: 2, 1: 3}},
+	}
+	for _, step := range steps {
+		sunk := g.Shot(mustCoord(t, step.coord), step.result)
+		if len(sunk) != step.wantSunk {
+			t.Errorf("Shot(%s) sunk %v, want a ship of length %d", step.coord, sunk, step.wantSunk)
+		}
+		if g.Turns != step.wantTurns {
+			t.Errorf("after %s: Turns = %d, want %d", step.coord, g.Turns, step.wantTurns)
+		}
+		if g.ShotsFired != step.wantFired || g.ShotsHit != step.wantHit {
+			t.Errorf("after %s: fired %d hit %d, want %d and %d", step.coord, g.ShotsFired, g.ShotsHit, step.wantFired, step.wantHit)
+		}
+		for length, count := range step.wantRemain {
+			if g.Remaining[length] != count {
+				t.Errorf("after %s: %d ships of length %d remain, want %d", step.coord, g.Remaining[length], length, count)
+			}
+		}
+	}
+
+	for _, c := range mustCoords(t, "C1", "C2") {
+		if got := g.Opponent.At(c); got != Sunk {
+			t.Errorf("%s is %v, want Sunk", c, got)
+		}
+	}
+	// The fields around a sunk ship can't hold another one.
+	for _, c := range mustCoords(t, "B1", "B3", "C3", "D1", "D3", "I9", "J9", "I10") {
+		if got := g.Opponent.At(c); got != Miss {
+			t.Errorf("%s next to a sunk ship is %v, want Miss", c, got)
+		}
+	}
+	for _, c := range mustCoords(t, "B1", "D3", "I9") {
+		found := false
+		for _, r := range g.RuledOut {
+			found = found || r == c
+		}
+		if !found {
+			t.Errorf("%s is not in RuledOut %v", c, g.RuledOut)
+		}
+	}
+	if g.FleetSunk() {
+		t.Error("FleetSunk() = true with ships left")
+	}
+}
+
+func TestGameFleetSunk(t *testing.T) {
+	rules, err := ParseRules("4x4:2,1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(rules, nil)
+	g.Shot(mustCoord(t, "A1"), ResultHit)
+	g.Shot(mustCoord(t, "A2"), ResultSunk)
+	if g.FleetSunk() {
+		t.Fatal("FleetSunk() = true with a ship left")
+	}
+	g.Shot(mustCoord(t, "D4"), ResultSunk)
+	if !g.FleetSunk() {
+		t.Error("FleetSunk() = false after all ships were sunk")
+	}
+}
+
+func TestGameOpponentShot(t *testing.T) {
+	g := New(StandardRules(), mustCoords(t, "A1", "A2", "C5"))
+
+	steps := []struct {
+		coord string
+		want  Result
+		cell  Cell
+	}{
+		{coord: "J10", want: ResultMiss, cell: Miss},
+		{coord: "A1", want: ResultHit, cell: Hit},
+		{coord: "A2", want: ResultSunk, cell: Sunk},
+		{coord: "C5", want: ResultSunk, cell: Sunk},
+		// A field shot again keeps its state.
+		{coord: "J10", want: ResultMiss, cell: Miss},
+	}
+	for _, step := range steps {
+		c := mustCoord(t, step.coord)
+		if got := g.OpponentShot(c); got != step.want {
+			t.Errorf("OpponentShot(%s) = %s, want %s", step.coord, got, step.want)
+		}
+		if got := g.Player.At(c); got != step.cell {
+			t.Errorf("after OpponentShot(%s) the field is %v, want %v", step.coord, got, step.cell)
+		}
+	}
+	if got := g.Player.At(mustCoord(t, "A1")); got != Sunk {
+		t.Errorf("A1 is %v after its ship was sunk, want Sunk", got)
+	}
+	if len(g.OpponentShots) != len(steps) {
+		t.Errorf("OpponentShots has %d shots, want %d", len(g.OpponentShots), len(steps))
+	}
+}
@@ -0,0 +1,95 @@
+package game
+
+// Inference is what the shots so far and the remaining fleet reveal about
+// the fields of the opponent's board that were not shot at.
+type Inference struct {
+	// Possible fields can hold at least one of the remaining ships.
+	Possible []Coord
+	// Impossible fields cannot hold any of the remaining ships.
+	Impossible []Coord
+	// Certain fields must be a part of a hit ship that was not sunk yet.
+	Certain []Coord
+}
+
+// Infer works out which unknown fields of board cannot contain a ship and
+// which must contain one, given the ships that are still afloat.
+//
+// A field is impossible when no remaining ship fits over it. The hits of an
+// unsunk ship all belong to the same ship, since ships can't touch, so the
+// fields shared by every placement covering those hits must be a part of it.
+func Infer(board *Board, remaining Fleet) Inference {
+	covered := make(map[Coord]bool)
+	var fitting []Ship
+	for length, count := range remaining {
+		if count <= 0 {
+			continue
+		}
+		for _, ship := range board.Placements(length) {
+			if !board.Fits(ship) {
+				continue
+			}
+			fitting = append(fitting, ship)
+			for _, c := range ship {
+				covered[c] = true
+			}
+		}
+	}
+
+	var inference Inference
+	for _, c := range board.Find(Empty) {
+		if covered[c] {
+			inference.Possible = append(inference.Possible, c)
+		} else {
+			inference.Impossible = append(inference.Impossible, c)
+		}
+	}
+
+	visited := make(map[Coord]bool)
+	for _, c := range board.Find(Hit) {
+		if visited[c] {
+			continue
+		}
+		hits := board.ShipAt(c)
+		for _, part := range hits {
+			visited[part] = true
+		}
+		inference.Certain = append(inference.Certain, certain(board, hits, fitting)...)
+	}
+
+	return inference
+}
+
+// certain returns the unknown fields shared by every placement in fitting
+// that covers all hits.
+func certain(board *Board, hits Ship, fitting []Ship) []Coord {
+	var shared map[Coord]bool
+	for _, ship := range fitting {
+		if !ship.Covers(hits) {
+			continue
+		}
+		if shared == nil {
+			shared = make(map[Coord]bool, len(ship))
+			for _, c := range ship {
+				shared[c] = true
+			}
+			continue
+		}
+		in := make(map[Coord]bool, len(ship))
+		for _, c := range ship {
+			in[c] = true
+		}
+		for c := range shared {
+			if !in[c] {
+				delete(shared, c)
+			}
+		}
+	}
+
+	var coords []Coord
+	for _, c := range board.Coords() {
+		if shared[c] && board.At(c) == Empty {
+			coords = append(coords, c)
+		}
+	}
+	return coords
+}
@@ -0,0 +1,118 @@
+package game
+
+import "testing"
+
+// boardWith returns a standard board with the given fields set to cell.
+func boardWith(t *testing.T, cells map[Cell][]string) *Board {
+	t.Helper()
+	b := NewBoard(Size, Size)
+	for cell, coords := range cells {
+		for _, c := range mustCoords(t, coords...) {
+			b.Set(c, cell)
+		}
+	}
+	return b
+}
+
+func contains(coords []Coord, c Coord) bool {
+	for _, other := range coords {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInferImpossibleGap(t *testing.T) {
+	// A1 and A2 are closed off in the corner, a gap of two fields.
+	board := boardWith(t, map[Cell][]string{
+		Miss: {"A3", "B1", "B2"},
+	})
+	gap := mustCoords(t, "A1", "A2")
+
+	inference := Infer(board, Fleet{3: 1})
+	for _, c := range gap {
+		if !contains(inference.Impossible, c) {
+			t.Errorf("%s is not impossible when only a ship of length 3 remains", c)
+		}
+	}
+	if c := mustCoord(t, "E5"); !contains(inference.Possible, c) {
+		t.Errorf("%s is not possible on an open part of the board", c)
+	}
+
+	inference = Infer(board, Fleet{3: 1, 2: 1})
+	for _, c := range gap {
+		if !contains(inference.Possible, c) {
+			t.Errorf("%s is not possible when a ship of length 2 remains", c)
+		}
+	}
+}
+
+func TestInferCertain(t *testing.T) {
+	// E5 is hit and can only extend sideways. C5 is a miss, so D5 is a gap
+	// of one field to the left.
+	board := boardWith(t, map[Cell][]string{
+		Hit:  {"E5"},
+		Miss: {"E4", "E6", "C5"},
+	})
+
+	tests := []struct {
+		name      string
+		remaining Fleet
+		want      []Coord
+	}{
+		// D5-F5 and E5-G5 fit, both take F5.
+		{name: "length 3", remaining: Fleet{3: 1}, want: mustCoords(t, "F5")},
+		// D5-G5 and E5-H5 fit, both take F5 and G5.
+		{name: "length 4", remaining: Fleet{4: 1}, want: mustCoords(t, "F5", "G5")},
+		// D5-E5 and E5-F5 fit, they share no unknown field.
+		{name: "length 2", remaining: Fleet{2: 1}, want: nil},
+		// The hit can belong to either ship, nothing is certain.
+		{name: "lengths 3 and 2", remaining: Fleet{3: 1, 2: 1}, want: nil},
+	}
+	for _, tt := range tests {
+		got := Infer(board, tt.remaining).Certain
+		if !sameCoords(got, tt.want) {
+			t.Errorf("%s: Certain = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInferDiagonalToHit(t *testing.T) {
+	board := boardWith(t, map[Cell][]string{
+		Hit: {"E5"},
+	})
+	inference := Infer(board, StandardFleet())
+	for _, c := range mustCoords(t, "D4", "F4", "D6", "F6") {
+		if !contains(inference.Impossible, c) {
+			t.Errorf("%s diagonal to a hit is not impossible", c)
+		}
+	}
+	for _, c := range mustCoords(t, "E4", "E6", "D5", "F5", "E3") {
+		if !contains(inference.Possible, c) {
+			t.Errorf("%s is not possible", c)
+		}
+	}
+	if len(inference.Certain) != 0 {
+		t.Errorf("Certain = %v for a lone hit with every direction open", inference.Certain)
+	}
+}
+
+func TestGameRulesOutInferredFields(t *testing.T) {
+	g := New(StandardRules(), nil)
+	g.Shot(mustCoord(t, "E5"), ResultHit)
+
+	for _, c := range mustCoords(t, "D4", "F4", "D6", "F6") {
+		if got := g.Opponent.At(c); got != Miss {
+			t.Errorf("%s diagonal to a hit is %v, want Miss", c, got)
+		}
+		if !contains(g.RuledOut, c) {
+			t.Errorf("%s is not in RuledOut", c)
+		}
+	}
+	for _, c := range mustCoords(t, "E4", "E6", "D5", "F5") {
+		if got := g.Opponent.At(c); got != Empty {
+			t.Errorf("%s next to a hit is %v, want Empty", c, got)
+		}
+	}
+}
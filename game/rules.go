@@ -0,0 +1,119 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxSize is the largest board side, as columns are named with letters.
+const maxSize = 'Z' - 'A' + 1
+
+// Rules describe a variant of the game: the size of the boards and the
+// ships each player places.
+type Rules struct {
+	Width  int
+	Height int
+	Fleet  Fleet
+}
+
+// StandardRules returns the rules used by the server: a 10x10 board and the
+// standard fleet.
+func StandardRules() Rules {
+	return Rules{
+		Width:  Size,
+		Height: Size,
+		Fleet:  StandardFleet(),
+	}
+}
+
+// ParseRules parses rules written as the board size optionally followed by
+// the lengths of all ships, e.g. "12x12" or "12x12:5,4,4,3,3,2,2". An empty
+// string or "standard" gives the standard rules.
+func ParseRules(s string) (Rules, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "standard" {
+		return StandardRules(), nil
+	}
+
+	rules := StandardRules()
+	size, ships, hasShips := strings.Cut(s, ":")
+	width, height, ok := strings.Cut(strings.ToLower(size), "x")
+	if !ok {
+		return Rules{}, fmt.Errorf("invalid rules %q: expected board size as WIDTHxHEIGHT, e.g. 12x12", s)
+	}
+	var err error
+	rules.Width, err = strconv.Atoi(strings.TrimSpace(width))
+	if err != nil {
+		return Rules{}, fmt.Errorf("invalid rules %q: board width must be a number", s)
+	}
+	rules.Height, err = strconv.Atoi(strings.TrimSpace(height))
+	if err != nil {
+		return Rules{}, fmt.Errorf("invalid rules %q: board height must be a number", s)
+	}
+	if hasShips {
+		rules.Fleet = make(Fleet)
+		for _, length := range strings.Split(ships, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return Rules{}, fmt.Errorf("invalid rules %q: ship length %q must be a number", s, length)
+			}
+			rules.Fleet[n]++
+		}
+	}
+
+	return rules, rules.Validate()
+}
+
+// Validate checks that the board can be named with coordinates and that
+// every ship fits on it.
+func (r Rules) Validate() error {
+	if r.Width < 1 || r.Width > maxSize || r.Height < 1 || r.Height > maxSize {
+		return fmt.Errorf("board must be from 1x1 to %dx%d, got %dx%d", maxSize, maxSize, r.Width, r.Height)
+	}
+	if r.Fleet.Empty() {
+		return fmt.Errorf("fleet must have at least one ship")
+	}
+	cells := 0
+	for length, count := range r.Fleet {
+		if length < 1 || length > r.Width && length > r.Height {
+			return fmt.Errorf("ships of length %d do not fit on a %dx%d board", length, r.Width, r.Height)
+		}
+		cells += length * count
+	}
+	if cells > r.Width*r.Height {
+		return fmt.Errorf("fleet of %d fields does not fit on a %dx%d board", cells, r.Width, r.Height)
+	}
+	return nil
+}
+
+// IsStandard reports whether r are the standard rules.
+func (r Rules) IsStandard() bool {
+	return r.String() == StandardRules().String()
+}
+
+// Ships returns the lengths of all ships in the fleet, longest first.
+func (r Rules) Ships() []int {
+	var ships []int
+	for _, length := range r.Fleet.Lengths() {
+		for i := 0; i < r.Fleet[length]; i++ {
+			ships = append(ships, length)
+		}
+	}
+	return ships
+}
+
+// String formats r the way ParseRules reads it.
+func (r Rules) String() string {
+	lengths := make([]string, 0, len(r.Fleet))
+	for _, length := range r.Ships() {
+		lengths = append(lengths, strconv.Itoa(length))
+	}
+	return fmt.Sprintf("%dx%d:%s", r.Width, r.Height, strings.Join(lengths, ","))
+}
+
+// ParseCoord parses a coordinate like the package-level ParseCoord, checking
+// that it lies on the board of these rules.
+func (r Rules) ParseCoord(s string) (Coord, error) {
+	return parseCoord(s, r.Width, r.Height)
+}
@@ -0,0 +1,100 @@
+// Package history keeps a local log of finished games and computes personal
+// statistics from it.
+package history
+
+import (
+	"battleship-client/config"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type Entry struct {
+	Player     string        `json:"player"`
+	Opponent   string        `json:"opponent"`
+	Result     string        `json:"result"`
+	ShotsFired int           `json:"shots_fired"`
+	ShotsHit   int           `json:"shots_hit"`
+	Turns      int           `json:"turns"`
+	Duration   time.Duration `json:"duration"`
+	StartedAt  time.Time     `json:"started_at"`
+	EndedAt    time.Time     `json:"ended_at"`
+}
+
+func (e Entry) Won() bool {
+	return e.Result == "win"
+}
+
+// Store appends entries to a file with one JSON object per line.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the store kept in history.jsonl in the user config
+// dir.
+func DefaultStore() (*Store, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(dir, "history.jsonl")), nil
+}
+
+func (s *Store) Append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error serializing history entry: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("error creating history dir: %s", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening history: %s", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing history: %s", err)
+	}
+	return nil
+}
+
+// Load returns all recorded entries, oldest first. Lines that can't be
+// parsed are skipped.
+func (s *Store) Load() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening history: %s", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history: %s", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].EndedAt.Before(entries[j].EndedAt)
+	})
+	return entries, nil
+}
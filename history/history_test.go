@@ -0,0 +1,92 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "battleship", "history.jsonl")
+	store := NewStore(path)
+
+	entries, err := store.Load()
+	if err != nil || entries != nil {
+		t.Fatalf("Load() = %v, %v without a history, want nil", entries, err)
+	}
+
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	later := Entry{Player: "me", Opponent: "bot", Result: "win", ShotsFired: 40, ShotsHit: 20, EndedAt: day.Add(time.Hour)}
+	earlier := Entry{Player: "me", Opponent: "bob", Result: "lose", ShotsFired: 30, ShotsHit: 10, EndedAt: day}
+	for _, e := range []Entry{later, earlier} {
+		if err := store.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A line cut short by a crash doesn't lose the rest of the history.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"player":"me","res` + "\n")
+	f.Close()
+
+	entries, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Entry{earlier, later}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("Load() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	monday := time.Date(2024, 4, 29, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Opponent: "bot", Result: "win", ShotsFired: 40, ShotsHit: 20, Turns: 10, Duration: 4 * time.Minute, EndedAt: monday},
+		{Opponent: "bob", Result: "lose", ShotsFired: 30, ShotsHit: 10, Turns: 8, Duration: 2 * time.Minute, EndedAt: monday.Add(24 * time.Hour)},
+		{Opponent: "bot", Result: "win", ShotsFired: 60, ShotsHit: 20, Turns: 12, Duration: 6 * time.Minute, EndedAt: monday.Add(7 * 24 * time.Hour)},
+		{Opponent: "amy", Result: "lose", ShotsFired: 10, ShotsHit: 5, Turns: 6, Duration: time.Minute, EndedAt: monday.Add(8 * 24 * time.Hour)},
+	}
+
+	s := Summarize(entries)
+
+	overall := s.Overall
+	if overall.Games != 4 || overall.Wins != 2 || overall.WinRate() != 0.5 {
+		t.Errorf("overall %d wins of %d games (%v), want 2 of 4", overall.Wins, overall.Games, overall.WinRate())
+	}
+	if overall.Accuracy() != 55.0/140 {
+		t.Errorf("accuracy = %v, want %v", overall.Accuracy(), 55.0/140)
+	}
+	if overall.AvgShotsToWin() != 50 || overall.AvgTurns() != 9 || overall.AvgDuration() != 13*time.Minute/4 {
+		t.Errorf("averages: %v shots to win, %v turns, %v, want 50, 9, %v", overall.AvgShotsToWin(), overall.AvgTurns(), overall.AvgDuration(), 13*time.Minute/4)
+	}
+
+	var opponents []string
+	for _, o := range s.ByOpponent {
+		opponents = append(opponents, o.Label)
+	}
+	if want := []string{"bot", "amy", "bob"}; !reflect.DeepEqual(opponents, want) {
+		t.Errorf("opponents = %v, want the most played first, then by name: %v", opponents, want)
+	}
+
+	var weeks []string
+	for _, w := range s.ByWeek {
+		weeks = append(weeks, w.Label)
+		if w.Games != 2 {
+			t.Errorf("week %s has %d games, want 2", w.Label, w.Games)
+		}
+	}
+	if want := []string{"2024-W18", "2024-W19"}; !reflect.DeepEqual(weeks, want) {
+		t.Errorf("weeks = %v, want %v", weeks, want)
+	}
+}
+
+func TestEmptyStats(t *testing.T) {
+	var s Stats
+	if s.WinRate() != 0 || s.Accuracy() != 0 || s.AvgShotsToWin() != 0 || s.AvgTurns() != 0 || s.AvgDuration() != 0 {
+		t.Errorf("stats of no games are %v, %v, %v, %v, %v, want zeros", s.WinRate(), s.Accuracy(), s.AvgShotsToWin(), s.AvgTurns(), s.AvgDuration())
+	}
+}
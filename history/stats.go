@@ -0,0 +1,120 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Stats aggregates a group of games.
+type Stats struct {
+	Label        string
+	Games        int
+	Wins         int
+	ShotsFired   int
+	ShotsHit     int
+	Turns        int
+	Duration     time.Duration
+	winningShots int
+}
+
+func (s *Stats) add(e Entry) {
+	s.Games++
+	s.ShotsFired += e.ShotsFired
+	s.ShotsHit += e.ShotsHit
+	s.Turns += e.Turns
+	s.Duration += e.Duration
+	if e.Won() {
+		s.Wins++
+		s.winningShots += e.ShotsFired
+	}
+}
+
+func (s Stats) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Games)
+}
+
+func (s Stats) Accuracy() float64 {
+	if s.ShotsFired == 0 {
+		return 0
+	}
+	return float64(s.ShotsHit) / float64(s.ShotsFired)
+}
+
+// AvgShotsToWin returns the average number of shots fired in won games.
+func (s Stats) AvgShotsToWin() float64 {
+	if s.Wins == 0 {
+		return 0
+	}
+	return float64(s.winningShots) / float64(s.Wins)
+}
+
+func (s Stats) AvgTurns() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Turns) / float64(s.Games)
+}
+
+func (s Stats) AvgDuration() time.Duration {
+	if s.Games == 0 {
+		return 0
+	}
+	return s.Duration / time.Duration(s.Games)
+}
+
+// Summary is the analysis of the whole history.
+type Summary struct {
+	Overall    Stats
+	ByOpponent []Stats
+	ByWeek     []Stats
+}
+
+// Summarize groups entries overall, per opponent (most played first) and per
+// ISO week (oldest first) to show trends over time.
+func Summarize(entries []Entry) Summary {
+	summary := Summary{Overall: Stats{Label: "all games"}}
+	opponents := make(map[string]*Stats)
+	weeks := make(map[string]*Stats)
+	var weekOrder []string
+
+	for _, e := range entries {
+		summary.Overall.add(e)
+
+		opp, ok := opponents[e.Opponent]
+		if !ok {
+			opp = &Stats{Label: e.Opponent}
+			opponents[e.Opponent] = opp
+		}
+		opp.add(e)
+
+		year, week := e.EndedAt.ISOWeek()
+		label := fmt.Sprintf("%d-W%02d", year, week)
+		w, ok := weeks[label]
+		if !ok {
+			w = &Stats{Label: label}
+			weeks[label] = w
+			weekOrder = append(weekOrder, label)
+		}
+		w.add(e)
+	}
+
+	for _, s := range opponents {
+		summary.ByOpponent = append(summary.ByOpponent, *s)
+	}
+	sort.Slice(summary.ByOpponent, func(i, j int) bool {
+		a, b := summary.ByOpponent[i], summary.ByOpponent[j]
+		if a.Games != b.Games {
+			return a.Games > b.Games
+		}
+		return a.Label < b.Label
+	})
+	sort.Strings(weekOrder)
+	for _, label := range weekOrder {
+		summary.ByWeek = append(summary.ByWeek, *weeks[label])
+	}
+	return summary
+}
@@ -2,11 +2,15 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +18,16 @@ type Client struct {
 	client http.Client
 	url    string
 	token  string
+	logger *slog.Logger
+}
+
+type call struct {
+	method   string
+	endpoint string
+	path     string
+	auth     bool
+	body     any
+	result   any
 }
 
 func NewClient(url string, timeout time.Duration) *Client {
@@ -21,11 +35,29 @@ func NewClient(url string, timeout time.Duration) *Client {
 		client: http.Client{
 			Timeout: timeout,
 		},
-		url: url,
+		url:    url,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 }
 
-func (c *Client) InitGame(coords []string, description string, nick string, targetNick string, wpbot bool) error {
+// Token returns the auth token of the current game, empty before InitGame.
+func (c *Client) Token() string {
+	return c.token
+}
+
+// SetToken makes the client act in the game the token was issued for, e.g.
+// one started before the client was restarted.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// SetLogger sets the logger that receives a debug record of every request
+// and its response.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+func (c *Client) InitGame(ctx context.Context, coords []string, description string, nick string, targetNick string, wpbot bool) error {
 	body := InitGameRequest{
 		Coords:     coords,
 		Desc:       description,
@@ -33,31 +65,17 @@ func (c *Client) InitGame(coords []string, description string, nick string, targ
 		TargetNick: targetNick,
 		Wpbot:      wpbot,
 	}
-	bodyJson, err := json.Marshal(body)
-	if err != nil {
-		return fmt.Errorf("error serializing InitGameRequestBody to json: %s", err)
-	}
 
-	requestUrl, err := url.JoinPath(c.url, "/game")
+	header, err := c.do(ctx, call{
+		method:   http.MethodPost,
+		endpoint: "/game",
+		body:     body,
+	})
 	if err != nil {
-		return fmt.Errorf("error creating url: %s", err)
+		return err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(bodyJson))
-	if err != nil {
-		return fmt.Errorf("error creating request: %s", err)
-	}
-
-	res, err := c.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending request: %s", err)
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
-
-	token := res.Header.Get("X-Auth-Token")
+	token := header.Get("X-Auth-Token")
 	if token == "" {
 		return fmt.Errorf("token is missing")
 	}
@@ -67,308 +85,235 @@ func (c *Client) InitGame(coords []string, description string, nick string, targ
 	return nil
 }
 
-func (c *Client) Board() ([]string, error) {
-	requestUrl, err := url.JoinPath(c.url, "/game/board")
-	if err != nil {
-		return nil, fmt.Errorf("error creating url: %s", err)
-	}
-
-	req, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %s", err)
-	}
-	req.Header.Set("X-Auth-Token", c.token)
-
-	res, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %s", err)
-	}
-
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
-
-	bodyJson, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading body: %s", err)
-	}
-
+func (c *Client) Board(ctx context.Context) ([]string, error) {
 	var body BoardResponse
-	err = json.Unmarshal(bodyJson, &body)
+	_, err := c.do(ctx, call{
+		method:   http.MethodGet,
+		endpoint: "/game/board",
+		auth:     true,
+		result:   &body,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error deserializing body: %s", err)
+		return nil, err
 	}
 
 	return body.Board, nil
 }
 
-func (c *Client) Status() (*StatusResponse, error) {
-	requestUrl, err := url.JoinPath(c.url, "/game")
-	if err != nil {
-		return nil, fmt.Errorf("error creating url: %s", err)
-	}
-
-	req, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %s", err)
-	}
-	req.Header.Set("X-Auth-Token", c.token)
-
-	res, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %s", err)
-	}
-
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
-
-	bodyJson, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading body: %s", err)
-	}
-
+func (c *Client) Status(ctx context.Context) (*StatusResponse, error) {
 	var body StatusResponse
-	err = json.Unmarshal(bodyJson, &body)
+	_, err := c.do(ctx, call{
+		method:   http.MethodGet,
+		endpoint: "/game",
+		auth:     true,
+		result:   &body,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error deserializing body: %s", err)
+		return nil, err
 	}
 
 	return &body, nil
 }
 
-func (c *Client) Description() (*DescriptionResponse, error) {
-	requestUrl, err := url.JoinPath(c.url, "/game/desc")
-	if err != nil {
-		return nil, fmt.Errorf("error creating url: %s", err)
-	}
-
-	req, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %s", err)
-	}
-	req.Header.Set("X-Auth-Token", c.token)
-
-	res, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %s", err)
-	}
-
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
-
-	bodyJson, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading body: %s", err)
-	}
-
+func (c *Client) Description(ctx context.Context) (*DescriptionResponse, error) {
 	var body DescriptionResponse
-	err = json.Unmarshal(bodyJson, &body)
+	_, err := c.do(ctx, call{
+		method:   http.MethodGet,
+		endpoint: "/game/desc",
+		auth:     true,
+		result:   &body,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error deserializing body: %s", err)
+		return nil, err
 	}
 
 	return &body, nil
 }
 
-func (c *Client) Fire(coord string) (*FireResponse, error) {
+func (c *Client) Fire(ctx context.Context, coord string) (*FireResponse, error) {
 	reqBody := FireRequest{
 		Coord: coord,
 	}
-	bodyJson, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("error serializing FireRequest to json: %s", err)
-	}
-
-	requestUrl, err := url.JoinPath(c.url, "/game/fire")
-	if err != nil {
-		return nil, fmt.Errorf("error creating url: %s", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(bodyJson))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %s", err)
-	}
-	req.Header.Set("X-Auth-Token", c.token)
-
-	res, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %s", err)
-	}
-
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
-
-	bodyJson, err = io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading body: %s", err)
-	}
 
 	var resBody FireResponse
-	err = json.Unmarshal(bodyJson, &resBody)
+	_, err := c.do(ctx, call{
+		method:   http.MethodPost,
+		endpoint: "/game/fire",
+		auth:     true,
+		body:     reqBody,
+		result:   &resBody,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error deserializing body: %s", err)
+		return nil, err
 	}
 
 	return &resBody, nil
 }
 
-func (c *Client) List() (*[]ListResponse, error) {
-	requestUrl, err := url.JoinPath(c.url, "/game/list")
-	if err != nil {
-		return nil, fmt.Errorf("error creating url: %s", err)
-	}
-
-	req, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %s", err)
-	}
-	req.Header.Set("X-Auth-Token", c.token)
-
-	res, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %s", err)
-	}
-
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
-
-	bodyJson, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading body: %s", err)
-	}
-
+func (c *Client) List(ctx context.Context) (*[]ListResponse, error) {
 	var resBody []ListResponse
-	err = json.Unmarshal(bodyJson, &resBody)
+	_, err := c.do(ctx, call{
+		method:   http.MethodGet,
+		endpoint: "/game/list",
+		auth:     true,
+		result:   &resBody,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error deserializing body: %s", err)
+		return nil, err
 	}
 
 	return &resBody, nil
 }
 
-func (c *Client) Refresh() error {
-	requestUrl, err := url.JoinPath(c.url, "/game/refresh")
-	if err != nil {
-		return fmt.Errorf("error creating url: %s", err)
-	}
+func (c *Client) Refresh(ctx context.Context) error {
+	_, err := c.do(ctx, call{
+		method:   http.MethodGet,
+		endpoint: "/game/refresh",
+		auth:     true,
+	})
+	return err
+}
 
-	req, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody)
+func (c *Client) Stats(ctx context.Context) (*StatsResponse, error) {
+	var resBody StatsResponse
+	_, err := c.do(ctx, call{
+		method:   http.MethodGet,
+		endpoint: "/stats",
+		result:   &resBody,
+	})
 	if err != nil {
-		return fmt.Errorf("error creating request: %s", err)
+		return nil, err
 	}
-	req.Header.Set("X-Auth-Token", c.token)
 
-	res, err := c.client.Do(req)
+	return &resBody, nil
+}
+
+func (c *Client) PlayerStats(ctx context.Context, player string) (*PlayerStatsResponse, error) {
+	var resBody PlayerStatsResponse
+	_, err := c.do(ctx, call{
+		method:   http.MethodGet,
+		endpoint: "/stats/{nick}",
+		path:     "/stats/" + player,
+		result:   &resBody,
+	})
 	if err != nil {
-		return fmt.Errorf("error sending request: %s", err)
+		return nil, err
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
+	return &resBody, nil
+}
 
-	return nil
+func (c *Client) Abandon(ctx context.Context) error {
+	_, err := c.do(ctx, call{
+		method:   http.MethodDelete,
+		endpoint: "/game/abandon",
+		auth:     true,
+	})
+	return err
 }
 
-func (c *Client) Stats() (*StatsResponse, error) {
-	requestUrl, err := url.JoinPath(c.url, "/stats")
-	if err != nil {
-		return nil, fmt.Errorf("error creating url: %s", err)
+// do sends the call using the retry policy registered for its endpoint and
+// decodes the response body into call.result. It returns the response headers.
+func (c *Client) do(ctx context.Context, cl call) (http.Header, error) {
+	var bodyJson []byte
+	if cl.body != nil {
+		var err error
+		bodyJson, err = json.Marshal(cl.body)
+		if err != nil {
+			return nil, fmt.Errorf("error serializing %T to json: %s", cl.body, err)
+		}
 	}
 
-	req, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %s", err)
+	path := cl.path
+	if path == "" {
+		path = cl.endpoint
 	}
-
-	res, err := c.client.Do(req)
+	requestUrl, err := url.JoinPath(c.url, path)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %s", err)
+		return nil, fmt.Errorf("error creating url: %s", err)
 	}
 
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
+	var header http.Header
+	err = PolicyFor(cl.method, cl.endpoint).Do(ctx, func() error {
+		header, err = c.send(ctx, cl, requestUrl, bodyJson)
+		return err
+	})
 
-	bodyJson, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading body: %s", err)
-	}
+	return header, err
+}
 
-	var resBody StatsResponse
-	err = json.Unmarshal(bodyJson, &resBody)
-	if err != nil {
-		return nil, fmt.Errorf("error deserializing body: %s", err)
+func (c *Client) send(ctx context.Context, cl call, requestUrl string, bodyJson []byte) (http.Header, error) {
+	var body io.Reader = http.NoBody
+	if bodyJson != nil {
+		body = bytes.NewReader(bodyJson)
 	}
 
-	return &resBody, nil
-}
-
-func (c *Client) PlayerStats(player string) (*PlayerStatsResponse, error) {
-	requestUrl, err := url.JoinPath(c.url, "/stats/"+player)
-	if err != nil {
-		return nil, fmt.Errorf("error creating url: %s", err)
+	var written atomic.Bool
+	trace := &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				written.Store(true)
+			}
+		},
 	}
 
-	req, err := http.NewRequest(http.MethodGet, requestUrl, http.NoBody)
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), cl.method, requestUrl, body)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %s", err)
 	}
+	if cl.auth {
+		req.Header.Set("X-Auth-Token", c.token)
+	}
 
+	start := time.Now()
 	res, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %s", err)
-	}
+		c.logger.Debug("request failed",
+			"method", cl.method,
+			"path", req.URL.Path,
+			"latency", time.Since(start),
+			"token", redact(req.Header.Get("X-Auth-Token")),
+			"error", err)
+		return nil, &SendError{Err: err, Written: written.Load()}
+	}
+	c.logger.Debug("request",
+		"method", cl.method,
+		"path", req.URL.Path,
+		"status", res.StatusCode,
+		"latency", time.Since(start),
+		"token", redact(req.Header.Get("X-Auth-Token")),
+		"response_token", redact(res.Header.Get("X-Auth-Token")))
 
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+		return nil, newAPIError(res, cl.endpoint)
 	}
 
-	bodyJson, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading body: %s", err)
+	if cl.result == nil {
+		return res.Header, nil
 	}
 
-	var resBody PlayerStatsResponse
-	err = json.Unmarshal(bodyJson, &resBody)
+	resJson, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error deserializing body: %s", err)
+		return nil, &SendError{Err: fmt.Errorf("error reading body: %s", err), Written: true}
 	}
 
-	return &resBody, nil
-}
-
-func (c *Client) Abandon() error {
-	requestUrl, err := url.JoinPath(c.url, "/game/abandon")
+	err = json.Unmarshal(resJson, cl.result)
 	if err != nil {
-		return fmt.Errorf("error creating url: %s", err)
+		return nil, fmt.Errorf("error deserializing body: %s", err)
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, requestUrl, http.NoBody)
-	if err != nil {
-		return fmt.Errorf("error creating request: %s", err)
-	}
-	req.Header.Set("X-Auth-Token", c.token)
+	return res.Header, nil
+}
 
-	res, err := c.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending request: %s", err)
+// redact hides all but the first characters of a token, enough to tell
+// sessions apart in the log.
+func redact(token string) string {
+	if token == "" {
+		return ""
 	}
-
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	if len(token) <= 8 {
+		return "***"
 	}
-
-	return nil
+	return token[:4] + "***"
 }
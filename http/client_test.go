@@ -0,0 +1,72 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{token: "", want: ""},
+		{token: "short", want: "***"},
+		{token: "12345678", want: "***"},
+		{token: "123456789", want: "1234***"},
+		{token: "0f9c2b7e-5d1a-4c3e-9b8f-6a2d4e1c7b90", want: "0f9c***"},
+	}
+
+	for _, tt := range tests {
+		if got := redact(tt.token); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+// TestDebugLogRedactsToken checks that the auth token, sent and received,
+// never shows in the debug log.
+func TestDebugLogRedactsToken(t *testing.T) {
+	const token = "0f9c2b7e-5d1a-4c3e-9b8f-6a2d4e1c7b90"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.Header().Set("X-Auth-Token", token)
+		}
+		w.Write([]byte("{}"))
+	}))
+
+	var log bytes.Buffer
+	client := NewClient(ts.URL, time.Second)
+	client.SetLogger(slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	ctx := context.Background()
+	if err := client.InitGame(ctx, nil, "", "", "", true); err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != token {
+		t.Fatalf("Token() = %q, want %q", client.Token(), token)
+	}
+	if _, err := client.Status(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Failed requests are logged as well.
+	ts.Close()
+	if _, err := client.Status(ctx); err == nil {
+		t.Fatal("Status returned no error with the server closed")
+	}
+
+	if strings.Contains(log.String(), token) {
+		t.Errorf("token shows in the debug log:\n%s", log.String())
+	}
+	for _, want := range []string{"response_token=0f9c***", "token=0f9c***", "request failed"} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("debug log is missing %q:\n%s", want, log.String())
+		}
+	}
+}
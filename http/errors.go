@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrBadRequest        = errors.New("bad request")
+	ErrUnauthorized      = errors.New("invalid or expired token")
+	ErrNotFound          = errors.New("not found")
+	ErrServerUnavailable = errors.New("server unavailable")
+)
+
+// APIError is returned by Client when the server responds with a status
+// other than 200. Use errors.Is with the Err* sentinels to classify it.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Endpoint   string
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+func newAPIError(res *http.Response, endpoint string) error {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Endpoint:   endpoint,
+	}
+
+	bodyJson, err := io.ReadAll(io.LimitReader(res.Body, 4096))
+	if err != nil {
+		return apiErr
+	}
+
+	var body errorResponse
+	if json.Unmarshal(bodyJson, &body) == nil {
+		apiErr.Message = body.Message
+		if apiErr.Message == "" {
+			apiErr.Message = body.Error
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(bodyJson))
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: unexpected status code: %d", e.Endpoint, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: unexpected status code: %d: %s", e.Endpoint, e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrServerUnavailable:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrBadRequest, ErrUnauthorized, ErrNotFound, ErrServerUnavailable}
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{status: http.StatusBadRequest, want: ErrBadRequest},
+		{status: http.StatusUnauthorized, want: ErrUnauthorized},
+		{status: http.StatusForbidden, want: ErrUnauthorized},
+		{status: http.StatusNotFound, want: ErrNotFound},
+		{status: http.StatusTooManyRequests, want: ErrServerUnavailable},
+		{status: http.StatusInternalServerError, want: ErrServerUnavailable},
+		{status: http.StatusServiceUnavailable, want: ErrServerUnavailable},
+		{status: http.StatusConflict, want: nil},
+	}
+
+	for _, tt := range tests {
+		// Wrapped like the errors returned by Client.
+		err := fmt.Errorf("could not fire: %w", &APIError{StatusCode: tt.status, Message: "whatever", Endpoint: "/game/fire"})
+		for _, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+				t.Errorf("status %d: errors.Is(err, %q) = %v", tt.status, sentinel, got)
+			}
+		}
+	}
+}
+
+func TestAPIErrorError(t *testing.T) {
+	tests := []struct {
+		err  *APIError
+		want string
+	}{
+		{
+			err:  &APIError{StatusCode: 400, Message: "invalid coord", Endpoint: "/game/fire"},
+			want: "/game/fire: unexpected status code: 400: invalid coord",
+		},
+		{
+			err:  &APIError{StatusCode: 503, Endpoint: "/game"},
+			want: "/game: unexpected status code: 503",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: `{"message":"game not found"}`, want: "game not found"},
+		{body: `{"error":"invalid token"}`, want: "invalid token"},
+		{body: "Bad Gateway\n", want: "Bad Gateway"},
+		{body: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		res := &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(strings.NewReader(tt.body))}
+		err := newAPIError(res, "/game")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("newAPIError returned %T, want *APIError", err)
+		}
+		if apiErr.Message != tt.want || apiErr.StatusCode != http.StatusBadGateway || apiErr.Endpoint != "/game" {
+			t.Errorf("body %q: got %+v, want message %q", tt.body, apiErr, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Event is a change in the game state reported by a Stream.
+type Event interface {
+	event()
+}
+
+// OpponentJoined is sent once, when the game starts.
+type OpponentJoined struct {
+	Opponent string
+}
+
+// OpponentShot is sent for every shot the opponent fired, in order.
+type OpponentShot struct {
+	Coord string
+}
+
+// YourTurn is sent once at the start of each of our turns.
+type YourTurn struct {
+	// Turn is the number of shots the opponent had fired when the turn
+	// began. It grows from one of our turns to the next, as the opponent
+	// fires at least once in between: a turn that runs out of time ends the
+	// game.
+	Turn int
+}
+
+// GameEnded is the last event of a finished game.
+type GameEnded struct {
+	Result string
+}
+
+// TimerTick is sent every second while the game is in progress with the
+// seconds left in the current turn.
+type TimerTick struct {
+	Remaining int
+}
+
+// StreamError is sent when the game can no longer be followed, e.g. when the
+// server forgot it or the token expired. It is the last event of the stream.
+type StreamError struct {
+	Err error
+}
+
+func (OpponentJoined) event() {}
+func (OpponentShot) event()   {}
+func (YourTurn) event()       {}
+func (GameEnded) event()      {}
+func (TimerTick) event()      {}
+func (StreamError) event()    {}
+
+// refreshInterval is how often the waiting list session is kept alive.
+const refreshInterval = 10 * time.Second
+
+// Stream polls the game status and turns it into events. It polls slowly
+// while waiting for an opponent and during our turn, and quickly during the
+// opponent's turn, backing off while nothing changes. While waiting for an
+// opponent it also keeps the session on the waiting list alive.
+type Stream struct {
+	client   *Client
+	interval time.Duration
+	events   chan Event
+	poll     chan struct{}
+}
+
+// Events starts following the current game until ctx is done or the game
+// ends. interval is the longest delay between polls during the opponent's
+// turn; waiting for an opponent and our own turn are polled 4 times slower.
+func (c *Client) Events(ctx context.Context, interval time.Duration) *Stream {
+	return c.EventsAfter(ctx, interval, 0)
+}
+
+// EventsAfter is like Events for a game whose first `shots` opponent shots
+// are already known, e.g. a game resumed after a restart. Those shots are
+// not sent again.
+func (c *Client) EventsAfter(ctx context.Context, interval time.Duration, shots int) *Stream {
+	s := &Stream{
+		client:   c,
+		interval: interval,
+		events:   make(chan Event, 64),
+		poll:     make(chan struct{}, 1),
+	}
+	go s.run(ctx, shots)
+	return s
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// stream stops.
+func (s *Stream) Events() <-chan Event {
+	return s.events
+}
+
+// Poll asks for the status to be polled right away, e.g. after a shot that
+// might have ended the game or our turn.
+func (s *Stream) Poll() {
+	select {
+	case s.poll <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Stream) run(ctx context.Context, shots int) {
+	defer close(s.events)
+
+	minInterval := s.interval / 4
+	maxInterval := s.interval * 4
+
+	var (
+		joined      bool
+		announced   = -1
+		timer       int
+		delay       = minInterval
+		lastRefresh = time.Now()
+		next        = time.After(0)
+	)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if joined && timer > 0 {
+				timer--
+				if !s.emit(ctx, TimerTick{Remaining: timer}) {
+					return
+				}
+			}
+			continue
+		case <-s.poll:
+		case <-next:
+		}
+
+		status, err := s.client.Status(ctx)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized) {
+			s.emit(ctx, StreamError{Err: err})
+			return
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			next = time.After(maxInterval)
+			continue
+		}
+
+		if status.GameStatus != "game_in_progress" && status.GameStatus != "ended" {
+			if time.Since(lastRefresh) >= refreshInterval {
+				err := s.client.Refresh(ctx)
+				if err != nil && ctx.Err() == nil {
+					s.emit(ctx, StreamError{Err: err})
+					return
+				}
+				lastRefresh = time.Now()
+			}
+			next = time.After(maxInterval)
+			continue
+		}
+
+		var events []Event
+		if !joined {
+			joined = true
+			events = append(events, OpponentJoined{Opponent: status.Opponent})
+		}
+		changed := false
+		for ; shots < len(status.OppShots); shots++ {
+			events = append(events, OpponentShot{Coord: status.OppShots[shots]})
+			changed = true
+		}
+		if status.Timer != timer {
+			timer = status.Timer
+			events = append(events, TimerTick{Remaining: timer})
+		}
+		// The turn is told by the status itself, so a status fetched
+		// before our last shot ended the turn can't announce it again.
+		ended := status.GameStatus == "ended"
+		if ended {
+			events = append(events, GameEnded{Result: status.LastGameStatus})
+		} else if turn := len(status.OppShots); status.ShouldFire && turn > announced {
+			announced = turn
+			events = append(events, YourTurn{Turn: turn})
+		}
+
+		for _, e := range events {
+			if !s.emit(ctx, e) {
+				return
+			}
+		}
+		if ended {
+			return
+		}
+
+		switch {
+		case status.ShouldFire:
+			delay = maxInterval
+		case changed || delay > s.interval || timer <= 5:
+			// The opponent is firing or is about to run out of time.
+			delay = minInterval
+		default:
+			delay = delay * 3 / 2
+			if delay > s.interval {
+				delay = s.interval
+			}
+		}
+		next = time.After(delay)
+	}
+}
+
+func (s *Stream) emit(ctx context.Context, e Event) bool {
+	select {
+	case s.events <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
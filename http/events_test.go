@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statusServer serves statuses in order to the status polls of a Stream,
+// repeating the last one once they run out.
+func statusServer(t *testing.T, statuses ...StatusResponse) *httptest.Server {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		polls int
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/game" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		status := statuses[min(polls, len(statuses)-1)]
+		polls++
+		mu.Unlock()
+		json.NewEncoder(w).Encode(status)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// collect returns the events of s until it stops.
+func collect(t *testing.T, s *Stream) []Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	var events []Event
+	for {
+		select {
+		case e, ok := <-s.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		case <-timeout:
+			t.Fatalf("stream did not stop, events so far: %v", events)
+		}
+	}
+}
+
+func inProgress(shouldFire bool, oppShots ...string) StatusResponse {
+	return StatusResponse{
+		GameStatus: "game_in_progress",
+		Opponent:   "bot",
+		OppShots:   append([]string{}, oppShots...),
+		ShouldFire: shouldFire,
+	}
+}
+
+func TestStreamAnnouncesEachTurnOnce(t *testing.T) {
+	ts := statusServer(t,
+		StatusResponse{GameStatus: "waiting"},
+		inProgress(true),
+		// Polled again while our shot that ended the turn was on its way.
+		inProgress(true),
+		inProgress(false),
+		inProgress(false, "A1"),
+		inProgress(false, "A1", "B2"),
+		inProgress(true, "A1", "B2"),
+		inProgress(true, "A1", "B2"),
+		StatusResponse{GameStatus: "ended", LastGameStatus: "win", OppShots: []string{"A1", "B2"}},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := collect(t, NewClient(ts.URL, time.Second).Events(ctx, 4*time.Millisecond))
+
+	want := []Event{
+		OpponentJoined{Opponent: "bot"},
+		YourTurn{Turn: 0},
+		OpponentShot{Coord: "A1"},
+		OpponentShot{Coord: "B2"},
+		YourTurn{Turn: 2},
+		GameEnded{Result: "win"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestStreamAfterKnownShots(t *testing.T) {
+	ts := statusServer(t,
+		inProgress(false, "A1", "B2"),
+		inProgress(true, "A1", "B2", "C3"),
+		StatusResponse{GameStatus: "ended", LastGameStatus: "lose", OppShots: []string{"A1", "B2", "C3"}},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := collect(t, NewClient(ts.URL, time.Second).EventsAfter(ctx, 4*time.Millisecond, 2))
+
+	want := []Event{
+		OpponentJoined{Opponent: "bot"},
+		OpponentShot{Coord: "C3"},
+		YourTurn{Turn: 3},
+		GameEnded{Result: "lose"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestStreamGameGone(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"game not found"}`, http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := collect(t, NewClient(ts.URL, time.Second).Events(ctx, 4*time.Millisecond))
+
+	if len(got) != 1 {
+		t.Fatalf("events = %v, want a single StreamError", got)
+	}
+	e, ok := got[0].(StreamError)
+	if !ok || !errors.Is(e.Err, ErrNotFound) {
+		t.Errorf("event = %v, want a StreamError wrapping ErrNotFound", got[0])
+	}
+}
+
+func TestStreamStopsWithContext(t *testing.T) {
+	ts := statusServer(t, inProgress(false))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewClient(ts.URL, time.Second).Events(ctx, 4*time.Millisecond)
+	if e := <-s.Events(); e != (OpponentJoined{Opponent: "bot"}) {
+		t.Fatalf("first event = %v, want OpponentJoined", e)
+	}
+	cancel()
+	collect(t, s)
+}
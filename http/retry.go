@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy describes how a failed request is retried. Delays grow
+// exponentially from BaseDelay up to MaxDelay, with up to half of each
+// delay replaced by random jitter.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Retryable   func(err error) bool
+}
+
+var (
+	// IdempotentRetry retries any transient failure: connection errors,
+	// 429 and 5xx responses.
+	IdempotentRetry = RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   250 * time.Millisecond,
+		MaxDelay:    4 * time.Second,
+		Retryable:   IsTransient,
+	}
+
+	// UnsafeRetry only retries requests that never reached the server, so
+	// a shot or a new game is never applied twice.
+	UnsafeRetry = RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   250 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Retryable:   IsNotSent,
+	}
+)
+
+var endpointPolicies = map[string]RetryPolicy{
+	http.MethodPost + " /game":           UnsafeRetry,
+	http.MethodGet + " /game":            IdempotentRetry,
+	http.MethodGet + " /game/board":      IdempotentRetry,
+	http.MethodGet + " /game/desc":       IdempotentRetry,
+	http.MethodPost + " /game/fire":      UnsafeRetry,
+	http.MethodGet + " /game/list":       IdempotentRetry,
+	http.MethodGet + " /game/refresh":    IdempotentRetry,
+	http.MethodDelete + " /game/abandon": IdempotentRetry,
+	http.MethodGet + " /stats":           IdempotentRetry,
+	http.MethodGet + " /stats/{nick}":    IdempotentRetry,
+}
+
+// SendError is returned when a request fails before a response is received.
+// Written reports whether the request had been fully sent to the server.
+type SendError struct {
+	Err     error
+	Written bool
+}
+
+func (e *SendError) Error() string {
+	return fmt.Sprintf("error sending request: %s", e.Err)
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// PolicyFor returns the retry policy used for the given method and endpoint.
+func PolicyFor(method string, endpoint string) RetryPolicy {
+	if policy, ok := endpointPolicies[method+" "+endpoint]; ok {
+		return policy
+	}
+	if method == http.MethodGet {
+		return IdempotentRetry
+	}
+	return UnsafeRetry
+}
+
+// Do calls f until it succeeds, returns an error the policy does not retry,
+// runs out of attempts or ctx is done.
+func (p RetryPolicy) Do(ctx context.Context, f func() error) error {
+	var err error
+	for attempt := 0; attempt < p.MaxAttempts || attempt == 0; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(p.delay(attempt)):
+			}
+		}
+
+		err = f()
+		if err == nil || ctx.Err() != nil || p.Retryable == nil || !p.Retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// IsTransient reports whether err is worth retrying for a request that is
+// safe to repeat.
+func IsTransient(err error) bool {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return true
+	}
+	return errors.Is(err, ErrServerUnavailable)
+}
+
+// IsNotSent reports whether err happened before the request was sent.
+func IsNotSent(err error) bool {
+	var sendErr *SendError
+	return errors.As(err, &sendErr) && !sendErr.Written
+}
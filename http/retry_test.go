@@ -0,0 +1,154 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPolicyFor(t *testing.T) {
+	tests := []struct {
+		method   string
+		endpoint string
+		want     RetryPolicy
+	}{
+		{method: http.MethodPost, endpoint: "/game", want: UnsafeRetry},
+		{method: http.MethodPost, endpoint: "/game/fire", want: UnsafeRetry},
+		{method: http.MethodGet, endpoint: "/game", want: IdempotentRetry},
+		{method: http.MethodGet, endpoint: "/stats/{nick}", want: IdempotentRetry},
+		{method: http.MethodDelete, endpoint: "/game/abandon", want: IdempotentRetry},
+		// Unknown endpoints are only retried when safe to repeat.
+		{method: http.MethodGet, endpoint: "/unknown", want: IdempotentRetry},
+		{method: http.MethodPut, endpoint: "/unknown", want: UnsafeRetry},
+	}
+
+	for _, tt := range tests {
+		got := PolicyFor(tt.method, tt.endpoint)
+		if got.MaxAttempts != tt.want.MaxAttempts || reflect.ValueOf(got.Retryable).Pointer() != reflect.ValueOf(tt.want.Retryable).Pointer() {
+			t.Errorf("PolicyFor(%s, %s) = %+v, want %+v", tt.method, tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 1; attempt <= 8; attempt++ {
+		full := min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+		for i := 0; i < 100; i++ {
+			if d := p.delay(attempt); d < full/2 || d > full {
+				t.Fatalf("delay(%d) = %v, want between %v and %v", attempt, d, full/2, full)
+			}
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := &SendError{Err: errors.New("connection reset")}
+	permanent := errors.New("bad request")
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Retryable: IsTransient}
+
+	tests := []struct {
+		name     string
+		errs     []error
+		want     error
+		attempts int
+	}{
+		{name: "success", errs: []error{nil}, want: nil, attempts: 1},
+		{name: "success after retries", errs: []error{transient, transient, nil}, want: nil, attempts: 3},
+		{name: "out of attempts", errs: []error{transient, transient, transient, nil}, want: transient, attempts: 3},
+		{name: "not retryable", errs: []error{permanent, nil}, want: permanent, attempts: 1},
+	}
+
+	for _, tt := range tests {
+		attempts := 0
+		err := p.Do(context.Background(), func() error {
+			attempts++
+			return tt.errs[attempts-1]
+		})
+		if err != tt.want || attempts != tt.attempts {
+			t.Errorf("%s: got %v after %d attempts, want %v after %d", tt.name, err, attempts, tt.want, tt.attempts)
+		}
+	}
+}
+
+func TestRetryPolicyDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour, Retryable: IsTransient}
+	attempts := 0
+	err := p.Do(ctx, func() error {
+		attempts++
+		cancel()
+		return &SendError{}
+	})
+	if attempts != 1 || err == nil {
+		t.Errorf("got %v after %d attempts, want the error of the only attempt", err, attempts)
+	}
+}
+
+// dropServer counts requests and closes each connection after reading the
+// request, without responding.
+func dropServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		io.Copy(io.Discard, r.Body)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requests
+}
+
+func TestDroppedAfterWrite(t *testing.T) {
+	ts, requests := dropServer(t)
+	client := NewClient(ts.URL, time.Second)
+
+	// A shot may have been applied, it must not be fired again.
+	_, err := client.Fire(context.Background(), "A1")
+	var sendErr *SendError
+	if !errors.As(err, &sendErr) || !sendErr.Written {
+		t.Fatalf("Fire returned %v, want a SendError for a written request", err)
+	}
+	if IsNotSent(err) {
+		t.Error("IsNotSent = true for a written request")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("shot sent %d times, want once", got)
+	}
+
+	// Polling the status is safe to repeat.
+	requests.Store(0)
+	p := PolicyFor(http.MethodGet, "/game")
+	if _, err := client.Status(context.Background()); err == nil {
+		t.Fatal("Status returned no error")
+	}
+	if got := requests.Load(); got != int32(p.MaxAttempts) {
+		t.Errorf("status sent %d times, want %d", got, p.MaxAttempts)
+	}
+}
+
+func TestDroppedBeforeWrite(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	_, err := NewClient(url, time.Second).Fire(context.Background(), "A1")
+	var sendErr *SendError
+	if !errors.As(err, &sendErr) || sendErr.Written {
+		t.Fatalf("Fire returned %v, want a SendError for a request never written", err)
+	}
+	if !IsNotSent(err) || !IsTransient(err) {
+		t.Errorf("IsNotSent = %v, IsTransient = %v, want both true", IsNotSent(err), IsTransient(err))
+	}
+}
@@ -0,0 +1,145 @@
+// Package layout stores named fleet layouts on disk, one JSON file per layout.
+package layout
+
+import (
+	"battleship-client/config"
+	"battleship-client/fleet"
+	"battleship-client/game"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+var ErrNotFound = errors.New("layout not found")
+
+type Store struct {
+	dir string
+}
+
+type savedLayout struct {
+	Name    string    `json:"name"`
+	Coords  []string  `json:"coords"`
+	SavedAt time.Time `json:"saved_at"`
+	// Rules are the house rules the layout was made for, empty for the
+	// standard ones.
+	Rules string `json:"rules,omitempty"`
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStore returns the store kept in the layouts directory of the user
+// config dir.
+func DefaultStore() (*Store, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(dir, "layouts")), nil
+}
+
+// Save stores coords as a layout for games played by rules.
+func (s *Store) Save(name string, coords []string, rules game.Rules) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid layout name %q: use up to 32 letters, digits, - or _", name)
+	}
+	if err := fleet.Validate(rules, coords); err != nil {
+		return fmt.Errorf("invalid layout: %s", err)
+	}
+
+	saved := savedLayout{
+		Name:    name,
+		Coords:  coords,
+		SavedAt: time.Now(),
+	}
+	if !rules.IsStandard() {
+		saved.Rules = rules.String()
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing layout: %s", err)
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("error creating layouts dir: %s", err)
+	}
+	if err := os.WriteFile(s.path(name), data, 0o644); err != nil {
+		return fmt.Errorf("error writing layout: %s", err)
+	}
+	return nil
+}
+
+// Load reads a layout and checks that it can be used in games played by
+// rules.
+func (s *Store) Load(name string, rules game.Rules) ([]string, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid layout name %q", name)
+	}
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading layout: %s", err)
+	}
+
+	var saved savedLayout
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error parsing layout %s: %s", name, err)
+	}
+	if saved.Rules != "" && saved.Rules != rules.String() {
+		return nil, fmt.Errorf("layout %s was made for rules %s, not %s", name, saved.Rules, rules)
+	}
+	if err := fleet.Validate(rules, saved.Coords); err != nil {
+		return nil, fmt.Errorf("layout %s is invalid: %s", name, err)
+	}
+	return saved.Coords, nil
+}
+
+// List returns the names of all saved layouts in alphabetical order.
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading layouts dir: %s", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if name != entry.Name() && !entry.IsDir() && validName.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *Store) Delete(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid layout name %q", name)
+	}
+	err := os.Remove(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting layout: %s", err)
+	}
+	return nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
@@ -0,0 +1,108 @@
+package layout
+
+import (
+	"battleship-client/game"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var standardFleet = []string{
+	"A1", "A2", "A3", "A4",
+	"C1", "C2", "C3",
+	"E1", "E2", "E3",
+	"G1", "G2",
+	"I1", "I2",
+	"A6", "A7",
+	"C5",
+	"E5",
+	"G5",
+	"I5",
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "layouts"))
+	rules := game.StandardRules()
+
+	names, err := store.List()
+	if err != nil || names != nil {
+		t.Fatalf("List() = %v, %v without a layouts dir, want nil", names, err)
+	}
+
+	for _, name := range []string{"corners", "Alpha_1"} {
+		if err := store.Save(name, standardFleet, rules); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err = store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Alpha_1", "corners"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+
+	coords, err := store.Load("corners", rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(coords, standardFleet) {
+		t.Errorf("Load() = %v, want %v", coords, standardFleet)
+	}
+
+	if err := store.Delete("corners"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("corners", rules); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load after Delete returned %v, want ErrNotFound", err)
+	}
+	if err := store.Delete("corners"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting a missing layout returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestSaveInvalid(t *testing.T) {
+	store := NewStore(t.TempDir())
+	rules := game.StandardRules()
+
+	for _, name := range []string{"", "../escape", "with space", "this-name-is-much-longer-than-32-chars"} {
+		if err := store.Save(name, standardFleet, rules); err == nil {
+			t.Errorf("Save(%q) returned no error", name)
+		}
+	}
+	if err := store.Save("short", standardFleet[:4], rules); err == nil {
+		t.Error("Save returned no error for an incomplete fleet")
+	}
+	if names, _ := store.List(); len(names) != 0 {
+		t.Errorf("invalid layouts were saved: %v", names)
+	}
+}
+
+func TestHouseRules(t *testing.T) {
+	store := NewStore(t.TempDir())
+	house, err := game.ParseRules("12x12:5,4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	coords := []string{"A1", "A2", "A3", "A4", "A5", "L9", "L10", "L11", "L12"}
+
+	if err := store.Save("house", coords, house); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Load("house", house); err != nil || !reflect.DeepEqual(got, coords) {
+		t.Errorf("Load() = %v, %v, want %v", got, err, coords)
+	}
+	if _, err := store.Load("house", game.StandardRules()); err == nil {
+		t.Error("a house rules layout loaded for the standard rules")
+	}
+
+	// Standard layouts don't record rules, but still only load where the
+	// fleet fits.
+	if err := store.Save("standard", standardFleet, game.StandardRules()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("standard", house); err == nil {
+		t.Error("a standard layout loaded for house rules")
+	}
+}
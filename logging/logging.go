@@ -0,0 +1,44 @@
+// Package logging opens the client's log file. The terminal belongs to the
+// GUI while a game is on, so diagnostics go to the file instead.
+package logging
+
+import (
+	"battleship-client/config"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPath returns the path of the log file in the client's directory.
+func DefaultPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "client.log"), nil
+}
+
+// Open appends to the log file at path, creating it if needed. Debug records
+// are only written when debug is set. The returned file must be closed when
+// the client exits.
+func Open(path string, debug bool) (*slog.Logger, *os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, fmt.Errorf("error creating log directory: %s", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening log file: %s", err)
+	}
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+	return slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: level})), f, nil
+}
+
+// Discard returns a logger that drops every record.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
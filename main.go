@@ -2,37 +2,115 @@ package main
 
 import (
 	"battleship-client/app"
+	"battleship-client/config"
 	"battleship-client/http"
+	"battleship-client/logging"
+	"battleship-client/replay"
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
-	"time"
+	"syscall"
 )
 
 func main() {
-	reader := bufio.NewReader(os.Stdin)
-	trimFunc := func(c rune) bool {
-		return c == '\r' || c == '\n'
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		playReplay(os.Args[2:])
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
-	fmt.Print("Enter your nick name or leave empty for random: ")
-	nick, err := reader.ReadString('\n')
 	if err != nil {
 		log.Fatal(err)
 	}
-	nick = strings.TrimRightFunc(nick, trimFunc)
-	description := ""
-	if nick != "" {
-		fmt.Print("Enter your description: ")
-		description, err = reader.ReadString('\n')
+
+	logger, logFile, err := openLog(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer logFile.Close()
+	logger.Info("client started", "server", cfg.ServerURL, "debug", cfg.Debug)
+
+	if cfg.Headless {
+		client := http.NewClient(cfg.ServerURL, cfg.Timeout.Duration())
+		client.SetLogger(logger)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := app.RunHeadless(ctx, client, cfg, os.Stdout, logger); err != nil && !errors.Is(err, context.Canceled) {
+			logger.Error("headless run failed", "error", err)
+			logFile.Close()
+			log.Fatal(err)
+		}
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	trimFunc := func(c rune) bool {
+		return c == '\r' || c == '\n'
+	}
+	if !cfg.NickSet {
+		fmt.Print("Enter your nick name or leave empty for random: ")
+		nick, err := reader.ReadString('\n')
 		if err != nil {
 			log.Fatal(err)
 		}
-		description = strings.TrimRightFunc(description, trimFunc)
+		cfg.Nick = strings.TrimRightFunc(nick, trimFunc)
+		if cfg.Nick != "" && cfg.Description == "" {
+			fmt.Print("Enter your description: ")
+			description, err := reader.ReadString('\n')
+			if err != nil {
+				log.Fatal(err)
+			}
+			cfg.Description = strings.TrimRightFunc(description, trimFunc)
+		}
 	}
 
-	client := http.NewClient("https://go-pjatk-server.fly.dev/api", time.Second*30)
+	client := http.NewClient(cfg.ServerURL, cfg.Timeout.Duration())
+	client.SetLogger(logger)
+
+	app.NewApp(client, cfg, logger)
+}
+
+// openLog opens the log file picked in cfg, or the default one.
+func openLog(cfg *config.Config) (*slog.Logger, *os.File, error) {
+	path := cfg.LogFile
+	if path == "" {
+		var err error
+		path, err = logging.DefaultPath()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return logging.Open(path, cfg.Debug)
+}
+
+func playReplay(args []string) {
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		dir, err := replay.Dir()
+		if err != nil {
+			log.Fatal(err)
+		}
+		path, err = replay.Latest(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	app.NewApp(client, reader, trimFunc, description, nick)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := app.PlayReplay(ctx, path); err != nil {
+		log.Fatal(err)
+	}
 }
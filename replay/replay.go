@@ -0,0 +1,243 @@
+// Package replay records games to JSON files and loads them back for
+// playback.
+package replay
+
+import (
+	"battleship-client/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventShot         = "shot"
+	EventOpponentShot = "opponent_shot"
+)
+
+type Replay struct {
+	Player              string    `json:"player"`
+	PlayerDescription   string    `json:"player_description"`
+	Opponent            string    `json:"opponent"`
+	OpponentDescription string    `json:"opponent_description"`
+	PlayerFleet         []string  `json:"player_fleet"`
+	OpponentFleet       []string  `json:"opponent_fleet"`
+	Rules               string    `json:"rules,omitempty"`
+	Events              []Event   `json:"events"`
+	Result              string    `json:"result"`
+	StartedAt           time.Time `json:"started_at"`
+	EndedAt             time.Time `json:"ended_at,omitempty"`
+}
+
+type Event struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Coord  string    `json:"coord"`
+	Result string    `json:"result"`
+}
+
+// Dir returns the directory replays are recorded to.
+func Dir() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "replays"), nil
+}
+
+func Load(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading replay: %s", err)
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("error parsing replay %s: %s", path, err)
+	}
+	return &r, nil
+}
+
+// Latest returns the path of the most recently recorded replay in dir.
+func Latest(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("no replays recorded yet")
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading replays dir: %s", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no replays recorded yet")
+	}
+	sort.Strings(names)
+	return filepath.Join(dir, names[len(names)-1]), nil
+}
+
+// Recorder writes a game to its replay file after every recorded event, so
+// the file stays usable even if the client stops mid-game. A nil Recorder
+// records nothing.
+type Recorder struct {
+	mu     sync.Mutex
+	path   string
+	replay Replay
+	ended  bool
+}
+
+func NewRecorder(dir string, replay Replay) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating replays dir: %s", err)
+	}
+	if replay.StartedAt.IsZero() {
+		replay.StartedAt = time.Now()
+	}
+	base := fmt.Sprintf("%s_%s_vs_%s",
+		replay.StartedAt.Format("2006-01-02T15-04-05"),
+		sanitize(replay.Player),
+		sanitize(replay.Opponent),
+	)
+	// Games started within the same second get a numbered suffix rather
+	// than overwrite each other.
+	for i := 1; ; i++ {
+		name := base + ".json"
+		if i > 1 {
+			name = fmt.Sprintf("%s_%d.json", base, i)
+		}
+		path := filepath.Join(dir, name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error creating replay: %s", err)
+		}
+		f.Close()
+
+		r := &Recorder{
+			path:   path,
+			replay: replay,
+		}
+		return r, r.save()
+	}
+}
+
+// Open continues recording to the replay file at path, e.g. for a game
+// resumed after the client was restarted.
+func Open(path string) (*Recorder, error) {
+	replay, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		path:   path,
+		replay: *replay,
+		ended:  replay.Result != "",
+	}
+	return r, nil
+}
+
+func (r *Recorder) Path() string {
+	if r == nil {
+		return ""
+	}
+	return r.path
+}
+
+// Replay returns a copy of what has been recorded so far.
+func (r *Recorder) Replay() Replay {
+	if r == nil {
+		return Replay{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replay := r.replay
+	replay.Events = append([]Event(nil), r.replay.Events...)
+	return replay
+}
+
+func (r *Recorder) Shot(coord string, result string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replay.Events = append(r.replay.Events, Event{
+		Time:   time.Now(),
+		Type:   EventShot,
+		Coord:  coord,
+		Result: result,
+	})
+	if result != "miss" {
+		r.replay.OpponentFleet = append(r.replay.OpponentFleet, coord)
+	}
+	_ = r.save()
+}
+
+// OpponentShot records the opponent's shot at coord and its result on our
+// board.
+func (r *Recorder) OpponentShot(coord string, result string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replay.Events = append(r.replay.Events, Event{
+		Time:   time.Now(),
+		Type:   EventOpponentShot,
+		Coord:  coord,
+		Result: result,
+	})
+	_ = r.save()
+}
+
+// End records the final game status. Only the first call has an effect.
+func (r *Recorder) End(result string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ended {
+		return
+	}
+	r.ended = true
+	r.replay.Result = result
+	r.replay.EndedAt = time.Now()
+	_ = r.save()
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.replay, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing replay: %s", err)
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing replay: %s", err)
+	}
+	return nil
+}
+
+func sanitize(nick string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, nick)
+}
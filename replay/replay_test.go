@@ -0,0 +1,146 @@
+package replay
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r, err := NewRecorder(dir, Replay{
+		Player:      "me",
+		Opponent:    "bot",
+		PlayerFleet: []string{"A1", "A2"},
+		StartedAt:   started,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Shot("B2", "miss")
+	r.OpponentShot("A1", "hit")
+	r.OpponentShot("A2", "sunk")
+	r.Shot("C3", "hit")
+	r.End("lose")
+	r.End("win")
+
+	got, err := Load(r.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	for _, e := range got.Events {
+		events = append(events, Event{Type: e.Type, Coord: e.Coord, Result: e.Result})
+	}
+	want := []Event{
+		{Type: EventShot, Coord: "B2", Result: "miss"},
+		{Type: EventOpponentShot, Coord: "A1", Result: "hit"},
+		{Type: EventOpponentShot, Coord: "A2", Result: "sunk"},
+		{Type: EventShot, Coord: "C3", Result: "hit"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	if got.Result != "lose" {
+		t.Errorf("result = %q, want the first one recorded", got.Result)
+	}
+	if !reflect.DeepEqual(got.OpponentFleet, []string{"C3"}) {
+		t.Errorf("opponent fleet = %v, want the field hit", got.OpponentFleet)
+	}
+	if !got.StartedAt.Equal(started) || got.EndedAt.IsZero() {
+		t.Errorf("started %v ended %v, want %v and the end recorded", got.StartedAt, got.EndedAt, started)
+	}
+}
+
+func TestRecorderSameSecond(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	paths := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		r, err := NewRecorder(dir, Replay{Player: "me", Opponent: "bot", StartedAt: started})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.End("win")
+		if paths[r.Path()] {
+			t.Fatalf("replay %s recorded twice", r.Path())
+		}
+		paths[r.Path()] = true
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("%d replay files recorded, want 3", len(files))
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRecorder(dir, Replay{Player: "me", Opponent: "bot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Shot("A1", "hit")
+
+	reopened, err := Open(r.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.Shot("A2", "sunk")
+	reopened.End("win")
+
+	got, err := Load(r.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Events) != 2 || got.Result != "win" {
+		t.Errorf("reopened replay has %d events and result %q, want 2 and win", len(got.Events), got.Result)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Latest(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Latest returned no error without a replays dir")
+	}
+
+	var last string
+	for _, day := range []int{3, 1, 2} {
+		r, err := NewRecorder(dir, Replay{
+			Player:    "me",
+			Opponent:  "bot",
+			StartedAt: time.Date(2024, 5, day, 12, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if day == 3 {
+			last = r.Path()
+		}
+	}
+
+	got, err := Latest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != last {
+		t.Errorf("Latest = %s, want %s", got, last)
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	r.Shot("A1", "miss")
+	r.OpponentShot("A1", "miss")
+	r.End("win")
+	if r.Path() != "" {
+		t.Errorf("Path() = %q for a nil recorder", r.Path())
+	}
+}
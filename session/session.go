@@ -0,0 +1,96 @@
+// Package session remembers the game in progress, so the client can return
+// to it after a crash or restart.
+package session
+
+import (
+	"battleship-client/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type Session struct {
+	ServerURL string    `json:"server_url"`
+	Token     string    `json:"token"`
+	Player    string    `json:"player"`
+	Opponent  string    `json:"opponent"`
+	Rules     string    `json:"rules,omitempty"`
+	Replay    string    `json:"replay,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	// Shots is the journal of our shots. The server only reports the
+	// opponent's, so it is the one record of ours to resume from.
+	Shots []Shot `json:"shots,omitempty"`
+}
+
+// Shot is one of our shots and its result.
+type Shot struct {
+	Coord  string `json:"coord"`
+	Result string `json:"result"`
+	// After is how many shots the opponent had fired before it, to replay
+	// both players' shots in order.
+	After int `json:"after"`
+}
+
+// Store keeps at most one session in a file.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the store kept in session.json in the user config
+// dir.
+func DefaultStore() (*Store, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(dir, "session.json")), nil
+}
+
+// Save replaces the stored session. The file holds the auth token, so only
+// the user can read it.
+func (s *Store) Save(session Session) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing session: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("error creating session dir: %s", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing session: %s", err)
+	}
+	return nil
+}
+
+// Load returns the stored session, or nil if there is none.
+func (s *Store) Load() (*Session, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading session: %s", err)
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("error parsing session %s: %s", s.path, err)
+	}
+	return &session, nil
+}
+
+// Clear forgets the stored session.
+func (s *Store) Clear() error {
+	err := os.Remove(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing session: %s", err)
+	}
+	return nil
+}
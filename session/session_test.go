@@ -0,0 +1,72 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "battleship", "session.json")
+	store := NewStore(path)
+
+	s, err := store.Load()
+	if err != nil || s != nil {
+		t.Fatalf("Load() = %v, %v without a session, want nil", s, err)
+	}
+
+	want := Session{
+		ServerURL: "http://localhost:8080/api/v1",
+		Token:     "secret-token",
+		Player:    "me",
+		Opponent:  "bot",
+		Rules:     "12x12:5,4",
+		Replay:    "/tmp/replay.json",
+		StartedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Shots: []Shot{
+			{Coord: "A1", Result: "miss"},
+			{Coord: "B2", Result: "hit", After: 3},
+			{Coord: "B3", Result: "sunk", After: 3},
+		},
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("session file mode is %v, want 0600", perm)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || !reflect.DeepEqual(*got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := store.Load(); err != nil || s != nil {
+		t.Errorf("Load() = %v, %v after Clear, want nil", s, err)
+	}
+	if err := store.Clear(); err != nil {
+		t.Errorf("Clear without a session: %v", err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(path).Load(); err == nil {
+		t.Error("Load returned no error for a corrupt session")
+	}
+}